@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/SirMetathyst/go-penguin"
+	"net/http"
+)
+
+func main() {
+	r := penguin.New()
+	r.HTMLGlob("./html/*.tmpl")
+
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		penguin.HTMLLayout(w, r, http.StatusOK, "base.tmpl", "page.tmpl", penguin.M{
+			"title":   "Home",
+			"message": "hello from page.tmpl",
+		})
+	})
+	r.Get("/about", func(w http.ResponseWriter, r *http.Request) {
+		penguin.HTMLLayout(w, r, http.StatusOK, "base.tmpl", "about.tmpl", penguin.M{"title": "About"})
+	})
+
+	http.ListenAndServe(":3333", r)
+}
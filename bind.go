@@ -0,0 +1,264 @@
+package penguin
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaxBodySize is the largest request body, in bytes, decoded by Bind and
+// BindStrict.
+var MaxBodySize int64 = 1 << 20
+
+// MaxStreamBodySize is the largest request body, in bytes, read by
+// DecodeJSONStream.
+var MaxStreamBodySize int64 = 100 << 20
+
+// MaxStreamElementSize is the largest array element, in bytes, passed on by
+// DecodeJSONStream.
+var MaxStreamElementSize = 1 << 20
+
+// Bind decodes the request body into 'v' as JSON or XML depending on the
+// request Content-Type. Bodies larger than MaxBodySize are rejected.
+//
+// The returned error is a *HTTPError carrying a 415 Unsupported Media Type for
+// any other content type, a 413 Request Entity Too Large for oversized bodies
+// and a 400 Bad Request for malformed bodies, ready to be passed to Error.
+func Bind(r *http.Request, v any) error {
+	return bind(r, v, false)
+}
+
+// BindStrict is like Bind but rejects JSON bodies with fields that aren't
+// present in 'v'.
+func BindStrict(r *http.Request, v any) error {
+	return bind(r, v, true)
+}
+
+func bind(r *http.Request, v any, strict bool) error {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body := http.MaxBytesReader(nil, r.Body, MaxBodySize)
+
+	var err error
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		dec := json.NewDecoder(body)
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		err = dec.Decode(v)
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		err = xml.NewDecoder(body).Decode(v)
+	default:
+		return NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported content type '%s'", mt))
+	}
+	return bindError(err)
+}
+
+// bindError converts a decoding error to a *HTTPError.
+func bindError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit))
+	}
+	if errors.Is(err, io.EOF) {
+		return NewHTTPError(http.StatusBadRequest, "empty request body")
+	}
+	return NewHTTPError(http.StatusBadRequest, "malformed request body: "+err.Error())
+}
+
+// BindForm parses the request form, see http.Request.ParseForm, and maps its
+// values onto the fields of the struct pointed to by 'v'. Multipart forms are
+// parsed with ParseMultipart. See BindQuery.
+func BindForm(r *http.Request, v any) error {
+	parse := r.ParseForm
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		parse = func() error { return ParseMultipart(r) }
+	}
+	if err := parse(); err != nil {
+		return NewHTTPError(http.StatusBadRequest, "malformed form: "+err.Error())
+	}
+	return bindValues(r.Form, v)
+}
+
+// BindQuery maps the URL query values of the request onto the fields of the
+// struct pointed to by 'v'. Fields are matched by their `form` struct tag,
+// falling back to the field name, and fields tagged `form:"-"` are skipped.
+// Fields of type string, bool, int, float64 and their sized variants are
+// supported, as well as slices of those which bind repeated values. Fields
+// without a value are left untouched.
+//
+// The returned error is a *HTTPError carrying a 400 Bad Request that lists the
+// fields whose value failed to convert. It panics if 'v' is not a pointer to a
+// struct.
+func BindQuery(r *http.Request, v any) error {
+	return bindValues(r.URL.Query(), v)
+}
+
+func bindValues(values url.Values, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("penguin: binding expects a pointer to a struct, got %T", v))
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	var invalid []string
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		name := f.Tag.Get("form")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		vs, ok := values[name]
+		if !ok || len(vs) == 0 {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+			for j, s := range vs {
+				if !setValue(slice.Index(j), s) {
+					invalid = append(invalid, name)
+					break
+				}
+			}
+			fv.Set(slice)
+			continue
+		}
+		if !setValue(fv, vs[0]) {
+			invalid = append(invalid, name)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return NewHTTPError(http.StatusBadRequest, "invalid value for fields: "+strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// setValue converts s to the kind of fv and sets it, reporting whether the
+// conversion succeeded.
+func setValue(fv reflect.Value, s string) bool {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return false
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return false
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return false
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return false
+		}
+		fv.SetFloat(n)
+	default:
+		return false
+	}
+	return true
+}
+
+// DecodeJSONStream reads a top-level JSON array from the request body one
+// element at a time, calling fn with each element, so bulk endpoints never hold
+// the whole array in memory. It stops at the first error returned by fn, and
+// between elements when the request context is done.
+//
+// Bodies larger than MaxStreamBodySize and elements larger than
+// MaxStreamElementSize are rejected with a *HTTPError carrying a 413 Request
+// Entity Too Large, while bodies that aren't a JSON array are rejected with a
+// 400 Bad Request.
+func DecodeJSONStream(r *http.Request, fn func(v json.RawMessage) error) error {
+	er := &elementReader{r: http.MaxBytesReader(nil, r.Body, MaxStreamBodySize), limit: MaxStreamElementSize + elementReadSize}
+	dec := json.NewDecoder(er)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return bindError(err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return NewHTTPError(http.StatusBadRequest, "malformed request body: expecting a JSON array")
+	}
+
+	for dec.More() {
+		if err := r.Context().Err(); err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		er.n = 0
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, errElementTooLarge) {
+				return NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("array element exceeds %d bytes", MaxStreamElementSize))
+			}
+			return bindError(err)
+		}
+		if len(raw) > MaxStreamElementSize {
+			return NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("array element exceeds %d bytes", MaxStreamElementSize))
+		}
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return bindError(err)
+	}
+	return nil
+}
+
+// errElementTooLarge is returned by an elementReader past its limit.
+var errElementTooLarge = errors.New("penguin: array element too large")
+
+// elementReadSize is the most an elementReader reads at once, which bounds the
+// bytes of the following elements the json.Decoder reads ahead.
+const elementReadSize = 4 << 10
+
+// elementReader counts the bytes read by DecodeJSONStream since the decoding
+// of the current array element started, failing with errElementTooLarge once
+// they exceed `limit` so an oversized element is never buffered whole.
+type elementReader struct {
+	r     io.Reader
+	n     int
+	limit int
+}
+
+func (er *elementReader) Read(p []byte) (int, error) {
+	if er.n > er.limit {
+		return 0, errElementTooLarge
+	}
+	if len(p) > elementReadSize {
+		p = p[:elementReadSize]
+	}
+	n, err := er.r.Read(p)
+	er.n += n
+	return n, err
+}
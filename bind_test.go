@@ -0,0 +1,211 @@
+package penguin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindUser struct {
+	Name string `json:"name" xml:"name"`
+	Age  int    `json:"age" xml:"age"`
+}
+
+func bindRequest(contentType, body string) *http.Request {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	return r
+}
+
+func bindStatus(err error) int {
+	var herr *HTTPError
+	if errors.As(err, &herr) {
+		return herr.StatusCode()
+	}
+	return 0
+}
+
+func TestBind(t *testing.T) {
+	var u bindUser
+	if err := Bind(bindRequest("application/json; charset=utf-8", `{"name":"gopher","age":13,"extra":true}`), &u); err != nil {
+		t.Fatal(err)
+	}
+	if u != (bindUser{"gopher", 13}) {
+		t.Fatalf("unexpected JSON binding %+v", u)
+	}
+
+	u = bindUser{}
+	if err := Bind(bindRequest("application/xml", `<user><name>gopher</name><age>13</age></user>`), &u); err != nil {
+		t.Fatal(err)
+	}
+	if u != (bindUser{"gopher", 13}) {
+		t.Fatalf("unexpected XML binding %+v", u)
+	}
+
+	tests := []struct {
+		name, contentType, body string
+		status                  int
+	}{
+		{"unsupported", "text/plain", "name=gopher", http.StatusUnsupportedMediaType},
+		{"malformed", "application/json", `{"name":`, http.StatusBadRequest},
+		{"empty", "application/json", ``, http.StatusBadRequest},
+		{"oversized", "application/json", `{"name":"` + strings.Repeat("a", 1<<20) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		if status := bindStatus(Bind(bindRequest(tt.contentType, tt.body), &bindUser{})); status != tt.status {
+			t.Errorf("%s: status %d, expecting %d", tt.name, status, tt.status)
+		}
+	}
+}
+
+func TestBindStrict(t *testing.T) {
+	err := BindStrict(bindRequest("application/json", `{"name":"gopher","extra":true}`), &bindUser{})
+	if bindStatus(err) != http.StatusBadRequest {
+		t.Fatalf("expecting a 400 for an unknown field, got %v", err)
+	}
+	if err := BindStrict(bindRequest("application/json", `{"name":"gopher"}`), &bindUser{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type bindFilter struct {
+	Query   string    `form:"q"`
+	Page    int       `form:"page"`
+	Active  bool      `form:"active"`
+	MinRate float64   `form:"min_rate"`
+	Tags    []string  `form:"tag"`
+	IDs     []int     `form:"id"`
+	Note    string    `form:"note"`
+	Ignored string    `form:"-"`
+	Weights []float64 `form:"w"`
+}
+
+func TestBindQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?q=penguin&page=2&active=true&min_rate=4.5&tag=go&tag=web&id=1&id=2&Ignored=x", nil)
+
+	f := bindFilter{Note: "default"}
+	if err := BindQuery(r, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Query != "penguin" || f.Page != 2 || !f.Active || f.MinRate != 4.5 {
+		t.Fatalf("unexpected scalar binding %+v", f)
+	}
+	if len(f.Tags) != 2 || f.Tags[0] != "go" || f.Tags[1] != "web" || len(f.IDs) != 2 || f.IDs[1] != 2 {
+		t.Fatalf("unexpected slice binding %+v", f)
+	}
+	if f.Note != "default" || f.Ignored != "" || f.Weights != nil {
+		t.Fatalf("expecting missing fields untouched, got %+v", f)
+	}
+
+	err := BindQuery(httptest.NewRequest("GET", "/?page=two&active=maybe&id=1&id=x", nil), &bindFilter{})
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.Status != http.StatusBadRequest || herr.Message != "invalid value for fields: page, active, id" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	r := bindRequest("application/x-www-form-urlencoded", "q=penguin&tag=a&tag=b")
+	r.URL.RawQuery = "page=3"
+
+	var f bindFilter
+	if err := BindForm(r, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Query != "penguin" || f.Page != 3 || len(f.Tags) != 2 {
+		t.Fatalf("unexpected form binding %+v", f)
+	}
+}
+
+func TestDecodeJSONStream(t *testing.T) {
+	var users []bindUser
+	collect := func(v json.RawMessage) error {
+		var u bindUser
+		if err := json.Unmarshal(v, &u); err != nil {
+			return err
+		}
+		users = append(users, u)
+		return nil
+	}
+
+	r := bindRequest("application/json", `[{"name":"a","age":1}, {"name":"b","age":2}]`)
+	if err := DecodeJSONStream(r, collect); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1] != (bindUser{"b", 2}) {
+		t.Fatalf("unexpected elements %+v", users)
+	}
+
+	for body, status := range map[string]int{
+		`{"name":"a"}`: http.StatusBadRequest,
+		`[{"name":"a"`: http.StatusBadRequest,
+		`["` + strings.Repeat("a", MaxStreamElementSize) + `"]`: http.StatusRequestEntityTooLarge,
+	} {
+		if s := bindStatus(DecodeJSONStream(bindRequest("application/json", body), collect)); s != status {
+			t.Errorf("body %.20q: status %d, expecting %d", body, s, status)
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := DecodeJSONStream(bindRequest("application/json", `[1, 2, 3]`), func(v json.RawMessage) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("expecting the callback error after one element, got %v after %d", err, calls)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += n
+	return n, err
+}
+
+func TestDecodeJSONStreamLargeElement(t *testing.T) {
+	body := &countingReader{r: io.MultiReader(
+		strings.NewReader(`[1, "`),
+		strings.NewReader(strings.Repeat("a", 10*MaxStreamElementSize)),
+		strings.NewReader(`"]`),
+	)}
+	r := httptest.NewRequest("POST", "/", body)
+	r.Header.Set("Content-Type", "application/json")
+
+	calls := 0
+	err := DecodeJSONStream(r, func(v json.RawMessage) error {
+		calls++
+		return nil
+	})
+	if s := bindStatus(err); s != http.StatusRequestEntityTooLarge || calls != 1 {
+		t.Fatalf("expecting a 413 after one element, got %d after %d", s, calls)
+	}
+	if body.n > 2*MaxStreamElementSize {
+		t.Fatalf("expecting the oversized element not to be read whole, read %d bytes", body.n)
+	}
+}
+
+func TestDecodeJSONStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := bindRequest("application/json", `[1, 2, 3]`).WithContext(ctx)
+
+	calls := 0
+	err := DecodeJSONStream(r, func(v json.RawMessage) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if err != context.Canceled || calls != 1 {
+		t.Fatalf("expecting context.Canceled after one element, got %v after %d", err, calls)
+	}
+}
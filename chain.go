@@ -7,6 +7,24 @@ func Chain(middlewares ...func(http.Handler) http.Handler) Middlewares {
 	return Middlewares(middlewares)
 }
 
+// NewChain returns a Middlewares stack of the middleware handlers, which can be
+// extended with Append and reused across routers and endpoints:
+//
+//	authStack := penguin.NewChain(middleware.RequestID, requireUser)
+//	r.Method("GET", "/account", authStack.HandlerFunc(showAccount))
+//	r.Method("GET", "/admin", authStack.Append(requireAdmin).HandlerFunc(showAdmin))
+func NewChain(middlewares ...func(http.Handler) http.Handler) Middlewares {
+	return append(Middlewares(nil), middlewares...)
+}
+
+// Append returns a new Middlewares stack with the middlewares added after
+// those of `mws`, leaving `mws` untouched.
+func (mws Middlewares) Append(middlewares ...func(http.Handler) http.Handler) Middlewares {
+	stack := make(Middlewares, 0, len(mws)+len(middlewares))
+	stack = append(stack, mws...)
+	return append(stack, middlewares...)
+}
+
 // Handler builds and returns a http.Handler from the chain of middlewares,
 // with `h http.Handler` as the final handler.
 func (mws Middlewares) Handler(h http.Handler) http.Handler {
@@ -0,0 +1,173 @@
+package penguin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var benchHandler http.Handler
+
+func BenchmarkChain(b *testing.B) {
+	mw := func(next http.Handler) http.Handler { return next }
+	endpoint := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, n := range []int{0, 1, 5, 20} {
+		mws := make([]func(http.Handler) http.Handler, n)
+		for i := range mws {
+			mws[i] = mw
+		}
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				benchHandler = Chain(mws...).Handler(endpoint)
+			}
+		})
+	}
+}
+
+// BenchmarkRegisterInline measures registering a large route tree through
+// nested With and Group scopes.
+func BenchmarkRegisterInline(b *testing.B) {
+	mw := func(next http.Handler) http.Handler { return next }
+	endpoint := func(w http.ResponseWriter, r *http.Request) {}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r := New()
+		r.Use(mw)
+		for g := 0; g < 50; g++ {
+			r.Group(func(r Router) {
+				r.Use(mw, mw)
+				for j := 0; j < 20; j++ {
+					r.With(mw).Get("/g"+strconv.Itoa(g)+"/r"+strconv.Itoa(j), endpoint)
+				}
+			})
+		}
+	}
+}
+
+func TestWithDoesNotShareStack(t *testing.T) {
+	header := func(v string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Mw", v)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	endpoint := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := New()
+	parent := r.With(header("parent"))
+	parent.Group(func(r Router) {
+		r.Use(header("a"))
+		r.Get("/a", endpoint)
+	})
+	parent.Group(func(r Router) {
+		r.Use(header("b"))
+		r.Get("/b", endpoint)
+	})
+	parent.Get("/parent", endpoint)
+
+	tests := map[string]string{"/a": "parent,a", "/b": "parent,b", "/parent": "parent"}
+	for path, expected := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if got := strings.Join(w.Header().Values("X-Mw"), ","); got != expected {
+			t.Errorf("%s: middlewares %q, expecting %q", path, got, expected)
+		}
+	}
+}
+
+func TestNewChain(t *testing.T) {
+	var order []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	endpoint := func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "endpoint")
+	}
+
+	base := NewChain(mw("a"), mw("b"))
+	admin := base.Append(mw("c"))
+	other := base.Append(mw("d"))
+
+	r := New()
+	r.Method("GET", "/", base.HandlerFunc(endpoint))
+	r.Method("GET", "/admin", admin.HandlerFunc(endpoint))
+	r.Method("GET", "/other", other.Handler(http.HandlerFunc(endpoint)))
+
+	tests := map[string]string{
+		"/":      "a,b,endpoint",
+		"/admin": "a,b,c,endpoint",
+		"/other": "a,b,d,endpoint",
+	}
+	for path, expected := range tests {
+		order = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		if got := strings.Join(order, ","); got != expected {
+			t.Errorf("%s: expecting execution order %s, got %s", path, expected, got)
+		}
+	}
+	if len(base) != 2 {
+		t.Fatalf("expecting Append to leave the base stack untouched, got %d middlewares", len(base))
+	}
+}
+
+func TestMethodInlineMiddlewares(t *testing.T) {
+	var order []string
+	mw := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	endpoint := func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "endpoint")
+	}
+
+	r := New()
+	r.Use(mw("use"))
+	r.Get("/guarded", endpoint, mw("a"), mw("b"))
+	r.Post("/guarded", endpoint)
+	r.Get("/open", endpoint)
+	r.With(mw("with")).Put("/both", endpoint, mw("inline"))
+
+	tests := []struct {
+		method, path string
+		expected     string
+	}{
+		{"GET", "/guarded", "use,a,b,endpoint"},
+		{"POST", "/guarded", "use,endpoint"},
+		{"GET", "/open", "use,endpoint"},
+		{"PUT", "/both", "use,with,inline,endpoint"},
+	}
+	for _, tt := range tests {
+		order = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+		if got := strings.Join(order, ","); got != tt.expected {
+			t.Errorf("%s %s: expecting execution order %s, got %s", tt.method, tt.path, tt.expected, got)
+		}
+	}
+
+	var walked int
+	Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if method == "GET" && route == "/guarded" {
+			walked = len(middlewares)
+		}
+		return nil
+	})
+	if walked != 3 {
+		t.Fatalf("expecting Walk to report the router and inline middlewares, got %d", walked)
+	}
+}
@@ -2,8 +2,13 @@ package penguin
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // URLParam returns the url parameter from a http.Request object.
@@ -22,6 +27,79 @@ func URLParamFromCtx(ctx context.Context, key string) string {
 	return ""
 }
 
+// RoutePattern returns the routing pattern matched so far by the request, such
+// as "/api/{v}/users/{id}", joined across any mounted sub-routers. Inside a
+// handler it is the full pattern of the route, inside the middleware of a
+// sub-router it is the pattern of the mount. It returns the empty string if
+// `ctx` has no routing context.
+func RoutePattern(ctx context.Context) string {
+	if rctx := RouteContext(ctx); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// ErrMissingURLParam is wrapped by the errors of the typed URL parameter
+// accessors, such as URLParamInt, when the route has no value for the key.
+var ErrMissingURLParam = errors.New("penguin: missing url param")
+
+// URLParamInt returns the url parameter from a http.Request object parsed as
+// an int. The returned error names the parameter and wraps ErrMissingURLParam
+// or the strconv parse error.
+func URLParamInt(r *http.Request, key string) (int, error) {
+	n, err := parseURLParam(r, key, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 0)
+	})
+	return int(n), err
+}
+
+// MustURLParamInt is like URLParamInt but panics if the parameter is missing
+// or isn't an int, for routes whose pattern already guarantees it, such as
+// `/{id:[0-9]+}`.
+func MustURLParamInt(r *http.Request, key string) int {
+	n, err := URLParamInt(r, key)
+	if err != nil {
+		panic(err.Error())
+	}
+	return n
+}
+
+// URLParamInt64 returns the url parameter from a http.Request object parsed as
+// an int64. See URLParamInt.
+func URLParamInt64(r *http.Request, key string) (int64, error) {
+	return parseURLParam(r, key, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// URLParamUint returns the url parameter from a http.Request object parsed as
+// an uint. See URLParamInt.
+func URLParamUint(r *http.Request, key string) (uint, error) {
+	n, err := parseURLParam(r, key, func(s string) (uint64, error) {
+		return strconv.ParseUint(s, 10, 0)
+	})
+	return uint(n), err
+}
+
+// URLParamBool returns the url parameter from a http.Request object parsed as
+// a bool, accepting the values of strconv.ParseBool. See URLParamInt.
+func URLParamBool(r *http.Request, key string) (bool, error) {
+	return parseURLParam(r, key, strconv.ParseBool)
+}
+
+func parseURLParam[T any](r *http.Request, key string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	s := URLParam(r, key)
+	if s == "" {
+		return zero, fmt.Errorf("%w '%s'", ErrMissingURLParam, key)
+	}
+	v, err := parse(s)
+	if err != nil {
+		return zero, fmt.Errorf("penguin: invalid url param '%s': %w", key, err)
+	}
+	return v, nil
+}
+
 // HTMLEngineFromCtx returns the html engine from a http.Request Context.
 func HTMLEngineFromCtx(ctx context.Context) ExecuteTemplate {
 	if rctx := RouteContext(ctx); rctx != nil {
@@ -30,6 +108,100 @@ func HTMLEngineFromCtx(ctx context.Context) ExecuteTemplate {
 	return nil
 }
 
+// TextEngineFromCtx returns the text engine from a http.Request Context.
+func TextEngineFromCtx(ctx context.Context) ExecuteTemplate {
+	if rctx := RouteContext(ctx); rctx != nil {
+		return rctx.TextEngine
+	}
+	return nil
+}
+
+// RequestIDFromCtx returns the request ID from a http.Request Context, as set
+// by the middleware.RequestID middleware. Returns the empty string if a request
+// ID cannot be found.
+func RequestIDFromCtx(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if reqID, ok := ctx.Value(RequestIDKey).(string); ok {
+		return reqID
+	}
+	return ""
+}
+
+// WithLocale returns a copy of ctx carrying the `locale` used by HTMLLocalized
+// to pick the template variant to render, such as `fr` or `fr-CA`.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, LocaleKey, locale)
+}
+
+// LocaleFromCtx returns the locale from a http.Request Context, as set by
+// WithLocale. Returns the empty string if a locale cannot be found.
+func LocaleFromCtx(ctx context.Context) string {
+	locale, _ := ctx.Value(LocaleKey).(string)
+	return locale
+}
+
+// WithTemplateData returns a copy of ctx carrying `data` as the base template
+// data, merged on top of any base data ctx already carries. HTML and the other
+// render helpers merge it with their data when that is an M too, preferring
+// the keys of the latter.
+func WithTemplateData(ctx context.Context, data M) context.Context {
+	base := TemplateDataFromCtx(ctx)
+	merged := make(M, len(base)+len(data))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, TemplateDataKey, merged)
+}
+
+// TemplateDataFromCtx returns the base template data from a http.Request
+// Context, as set by WithTemplateData. Returns nil if there is none.
+func TemplateDataFromCtx(ctx context.Context) M {
+	data, _ := ctx.Value(TemplateDataKey).(M)
+	return data
+}
+
+// templateRecorder collects the names of the templates executed by HTML.
+type templateRecorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (rec *templateRecorder) record(name string) {
+	rec.mu.Lock()
+	rec.names = append(rec.names, name)
+	rec.mu.Unlock()
+}
+
+// RecordTemplates returns a copy of ctx that records the name of every
+// template executed by HTML for requests served with it, for use in tests:
+//
+//	ctx := penguin.RecordTemplates(context.Background())
+//	r.ServeHTTP(w, req.WithContext(ctx))
+//	names := penguin.RenderedTemplates(ctx)
+//
+// Requests served without a recording context only pay for a context lookup.
+func RecordTemplates(ctx context.Context) context.Context {
+	return context.WithValue(ctx, templateRecorderKey, &templateRecorder{})
+}
+
+// RenderedTemplates returns the names of the templates executed by HTML, in
+// order, for requests served with a context returned by RecordTemplates. It
+// returns nil if ctx isn't recording.
+func RenderedTemplates(ctx context.Context) []string {
+	rec, _ := ctx.Value(templateRecorderKey).(*templateRecorder)
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]string(nil), rec.names...)
+}
+
 // RouteContext returns chi's routing Context object from a
 // http.Request Context.
 func RouteContext(ctx context.Context) *Context {
@@ -45,6 +217,18 @@ func NewRouteContext() *Context {
 var (
 	// RouteCtxKey is the context.Context key to store the request context.
 	RouteCtxKey = &contextKey{"RouteContext"}
+
+	// RequestIDKey is the context.Context key to store the request ID.
+	RequestIDKey = &contextKey{"RequestID"}
+
+	// LocaleKey is the context.Context key to store the request locale.
+	LocaleKey = &contextKey{"Locale"}
+
+	// TemplateDataKey is the context.Context key to store the base template
+	// data merged by HTML.
+	TemplateDataKey = &contextKey{"TemplateData"}
+
+	templateRecorderKey = &contextKey{"TemplateRecorder"}
 )
 
 // Context is the default routing context set on the root node of a
@@ -85,7 +269,12 @@ type Context struct {
 	// methodNotAllowed hint
 	methodNotAllowed bool
 
+	// methods registered on the routes that matched the path but not the
+	// method of the request, see AllowedMethods.
+	methodsAllowed []methodTyp
+
 	HTMLEngine ExecuteTemplate
+	TextEngine ExecuteTemplate
 }
 
 // Reset a routing context to its initial state.
@@ -101,10 +290,12 @@ func (x *Context) Reset() {
 	x.routeParams.Keys = x.routeParams.Keys[:0]
 	x.routeParams.Values = x.routeParams.Values[:0]
 	x.methodNotAllowed = false
+	x.methodsAllowed = x.methodsAllowed[:0]
 	x.parentCtx = nil
 
 	// PENGUIN EXTRA'S
 	x.HTMLEngine = nil
+	x.TextEngine = nil
 }
 
 // URLParam returns the corresponding URL parameter value from the request
@@ -140,6 +331,34 @@ func (x *Context) RoutePattern() string {
 	return routePattern
 }
 
+// AllowedMethods returns the sorted http methods registered on the route that
+// matched the request path when the request method didn't match, as used by
+// the default MethodNotAllowed handler to set the Allow header.
+func (x *Context) AllowedMethods() []string {
+	var mts methodTyp
+	for _, mt := range x.methodsAllowed {
+		mts |= mt
+	}
+	var methods []string
+	for method, mt := range methodMap {
+		if mts&mt == mt {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// allowsMethod reports whether AllowedMethods includes the method mt.
+func (x *Context) allowsMethod(mt methodTyp) bool {
+	for _, m := range x.methodsAllowed {
+		if m == mt {
+			return true
+		}
+	}
+	return false
+}
+
 // replaceWildcards takes a route pattern and recursively replaces all
 // occurrences of "/*/" to "/".
 func replaceWildcards(p string) string {
@@ -1,6 +1,14 @@
 package penguin
 
-import "testing"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
 
 // TestRoutePattern tests correct in-the-middle wildcard removals.
 // If user organizes a router like this:
@@ -76,3 +84,87 @@ func TestRoutePattern(t *testing.T) {
 		t.Fatal("unexpected route pattern: " + p)
 	}
 }
+
+func TestRoutePatternFromCtx(t *testing.T) {
+	var mountPattern, pattern string
+
+	r := New()
+	r.Route("/api/{v}", func(r Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mountPattern = RoutePattern(r.Context())
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			pattern = RoutePattern(r.Context())
+		})
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/users/42", nil))
+	if pattern != "/api/{v}/users/{id}" {
+		t.Fatalf("unexpected route pattern: %q", pattern)
+	}
+	if mountPattern != "/api/{v}/*" {
+		t.Fatalf("unexpected mount pattern: %q", mountPattern)
+	}
+	if p := RoutePattern(httptest.NewRequest("GET", "/", nil).Context()); p != "" {
+		t.Fatalf("expecting no route pattern without a routing context, got %q", p)
+	}
+}
+
+func TestURLParamTyped(t *testing.T) {
+	var failures []error
+
+	r := New()
+	r.Get("/{id}/{flag}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := URLParamInt(r, "id")
+		if err != nil {
+			failures = append(failures, err)
+		}
+		id64, err := URLParamInt64(r, "id")
+		if err != nil {
+			failures = append(failures, err)
+		}
+		uid, err := URLParamUint(r, "id")
+		if err != nil {
+			failures = append(failures, err)
+		}
+		flag, err := URLParamBool(r, "flag")
+		if err != nil {
+			failures = append(failures, err)
+		}
+		fmt.Fprintf(w, "%d %d %d %t", id, id64, uid, flag)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/42/true", nil))
+	if w.Body.String() != "42 42 42 true" || len(failures) != 0 {
+		t.Fatalf("unexpected parsing %q %v", w.Body.String(), failures)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/-1/nope", nil))
+	if len(failures) != 2 {
+		t.Fatalf("expecting the uint and bool parsing to fail, got %v", failures)
+	}
+	if !errors.Is(failures[0], strconv.ErrSyntax) && !errors.Is(failures[0], strconv.ErrRange) {
+		t.Fatalf("expecting a wrapped strconv error, got %v", failures[0])
+	}
+	if msg := failures[1].Error(); !strings.Contains(msg, "'flag'") {
+		t.Fatalf("expecting the error to name the param, got %q", msg)
+	}
+}
+
+func TestURLParamTypedMissing(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	if _, err := URLParamInt(r, "id"); !errors.Is(err, ErrMissingURLParam) {
+		t.Fatalf("expecting ErrMissingURLParam, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting MustURLParamInt to panic")
+		}
+	}()
+	MustURLParamInt(r, "id")
+}
@@ -0,0 +1,76 @@
+package penguin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SessionOptions configures the attributes of a cookie set by SessionCookie.
+type SessionOptions struct {
+	// Path defaults to "/" so the cookie is sent for the whole site.
+	Path string
+
+	// Domain defaults to none, making the cookie host-only, which is the most
+	// restrictive choice. Set it to share the cookie with sub-domains.
+	Domain string
+
+	// MaxAge is how long the cookie lasts, a zero MaxAge makes a session
+	// cookie removed when the browser closes and a negative one deletes the
+	// cookie.
+	MaxAge time.Duration
+
+	// SameSite defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+
+	// AllowScript makes the cookie readable by JavaScript, it's HttpOnly
+	// otherwise.
+	AllowScript bool
+}
+
+// SessionCookie sets a cookie with secure defaults for session data: HttpOnly,
+// SameSite=Lax and a "/" path unless `opts` says otherwise. The cookie is
+// Secure when the request was made over HTTPS, see IsHTTPS, so the same code
+// works over plain HTTP in development and HTTPS in production.
+//
+// A cookie with SameSite=None is always Secure since browsers reject it
+// otherwise, and a `__Host-` or `__Secure-` prefixed name has the attributes
+// its prefix requires enforced.
+func SessionCookie(w http.ResponseWriter, r *http.Request, name, value string, opts SessionOptions) {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		Secure:   IsHTTPS(r),
+		HttpOnly: !opts.AllowScript,
+		SameSite: opts.SameSite,
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 || c.SameSite == http.SameSiteDefaultMode {
+		c.SameSite = http.SameSiteLaxMode
+	}
+	if c.SameSite == http.SameSiteNoneMode {
+		c.Secure = true
+	}
+
+	switch {
+	case opts.MaxAge < 0:
+		c.MaxAge = -1
+		c.Expires = time.Unix(0, 0)
+	case opts.MaxAge > 0:
+		c.MaxAge = int(opts.MaxAge / time.Second)
+		c.Expires = time.Now().Add(opts.MaxAge)
+	}
+
+	if strings.HasPrefix(name, "__Secure-") {
+		c.Secure = true
+	}
+	if strings.HasPrefix(name, "__Host-") {
+		c.Secure, c.Path, c.Domain = true, "/", ""
+	}
+
+	http.SetCookie(w, c)
+}
@@ -0,0 +1,55 @@
+package penguin
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func sessionCookie(r *http.Request, name string, opts SessionOptions) *http.Cookie {
+	w := httptest.NewRecorder()
+	SessionCookie(w, r, name, "v", opts)
+	return w.Result().Cookies()[0]
+}
+
+func TestSessionCookie(t *testing.T) {
+	plain := httptest.NewRequest("GET", "/", nil)
+
+	c := sessionCookie(plain, "sid", SessionOptions{})
+	if c.Secure || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Path != "/" || c.Domain != "" || c.MaxAge != 0 {
+		t.Fatalf("unexpected defaults over HTTP %+v", c)
+	}
+
+	tlsReq := httptest.NewRequest("GET", "/", nil)
+	tlsReq.TLS = &tls.ConnectionState{}
+	if c := sessionCookie(tlsReq, "sid", SessionOptions{}); !c.Secure {
+		t.Fatal("expecting a Secure cookie over HTTPS")
+	}
+
+	proxied := httptest.NewRequest("GET", "/", nil)
+	proxied.Header.Set("X-Forwarded-Proto", "https")
+	if c := sessionCookie(proxied, "sid", SessionOptions{}); !c.Secure {
+		t.Fatal("expecting a Secure cookie behind a HTTPS proxy")
+	}
+
+	c = sessionCookie(plain, "sid", SessionOptions{
+		Path: "/app", Domain: "example.com", MaxAge: time.Hour, SameSite: http.SameSiteStrictMode, AllowScript: true,
+	})
+	if c.Path != "/app" || c.Domain != "example.com" || c.MaxAge != 3600 || c.SameSite != http.SameSiteStrictMode || c.HttpOnly {
+		t.Fatalf("unexpected overridden attributes %+v", c)
+	}
+
+	if c := sessionCookie(plain, "sid", SessionOptions{SameSite: http.SameSiteNoneMode}); !c.Secure {
+		t.Fatal("expecting SameSite=None to force Secure")
+	}
+	if c := sessionCookie(plain, "sid", SessionOptions{MaxAge: -1}); c.MaxAge != -1 {
+		t.Fatalf("expecting a deleted cookie, got MaxAge %d", c.MaxAge)
+	}
+
+	c = sessionCookie(plain, "__Host-sid", SessionOptions{Path: "/app", Domain: "example.com"})
+	if !c.Secure || c.Path != "/" || c.Domain != "" {
+		t.Fatalf("expecting the __Host- prefix requirements, got %+v", c)
+	}
+}
@@ -2,16 +2,32 @@ package penguin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"text/tabwriter"
+	texttemplate "text/template"
+	"time"
 )
 
 var _ Router = &Engine{}
 
+// RequestIDHeader is the name of the HTTP Header the default NotFound and
+// MethodNotAllowed handlers use to return the request ID.
+var RequestIDHeader = "X-Request-Id"
+
 // Engine is a simple HTTP route multiplexer that parses a request path,
 // records any URL params, and executes an end handler. It implements
 // the http.Handler interface and is friendly with the standard library.
@@ -28,6 +44,10 @@ type Engine struct {
 	// The radix trie router
 	tree *node
 
+	// Guards the routing tree, which is shared with inline muxes, so that
+	// routes may be removed while requests are being served
+	mu *sync.RWMutex
+
 	// Custom method not allowed handler
 	methodNotAllowedHandler http.HandlerFunc
 
@@ -36,7 +56,7 @@ type Engine struct {
 	parent *Engine
 
 	// Routing context pool
-	pool *sync.Pool
+	pool contextPool
 
 	// Custom route not found handler
 	notFoundHandler http.HandlerFunc
@@ -47,16 +67,37 @@ type Engine struct {
 	// Controls the behaviour of middleware chain generation when a mux
 	// is registered as an inline group inside another mux.
 	inline bool
+
+	// Route patterns keyed by name, shared with inline muxes and guarded
+	// by mu. See Engine.Name.
+	names map[string]string
+
+	// Answer OPTIONS requests for routes without an OPTIONS handler with
+	// their allowed methods. See Engine.AutoOptions.
+	autoOptions bool
+
+	// Serve HEAD requests for routes without a HEAD handler with their GET
+	// handler. See Engine.AutoHead.
+	autoHead bool
+
+	// Redirect requests that only match a route with the trailing slash added
+	// or removed. See Engine.RedirectTrailingSlash.
+	redirectTrailingSlash bool
 }
 
 // New returns a newly initialized Engine object that implements the Router
 // interface.
 func New() *Engine {
-	mux := &Engine{tree: &node{}, pool: &sync.Pool{}}
-	mux.pool.New = func() interface{} {
+	pool := &sync.Pool{New: func() interface{} {
 		return NewRouteContext()
-	}
-	return mux
+	}}
+	return &Engine{tree: &node{}, pool: pool, mu: &sync.RWMutex{}, names: map[string]string{}}
+}
+
+// contextPool is the pool of routing contexts of an Engine, a *sync.Pool.
+type contextPool interface {
+	Get() any
+	Put(any)
 }
 
 // ServeHTTP is the single method of the http.Handler interface that makes
@@ -88,9 +129,10 @@ func (mx *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// NOTE: r.WithContext() causes 2 allocations and context.WithValue() causes 1 allocation
 	r = r.WithContext(context.WithValue(r.Context(), RouteCtxKey, rctx))
 
-	// Serve the request and once its done, put the request context back in the sync pool
+	// Serve the request and once its done, put the request context back in the
+	// sync pool, even if the handler panics.
+	defer mx.pool.Put(rctx)
 	mx.handler.ServeHTTP(w, r)
-	mx.pool.Put(rctx)
 }
 
 // Use appends a middleware handler to the Engine middleware stack.
@@ -106,28 +148,95 @@ func (mx *Engine) Use(middlewares ...func(http.Handler) http.Handler) {
 	mx.middlewares = append(mx.middlewares, middlewares...)
 }
 
-// HTML takes an ExecuteTemplate interface to handle execution of templates.
+// HTML takes an ExecuteTemplate interface to handle execution of templates. It's the same as Engine.HTMLEngine.
 func (mx *Engine) HTML(handler ExecuteTemplate) {
+	mx.HTMLEngine(handler)
+}
+
+// HTMLEngine calls Engine.Use with middleware that injects `engine` into each request for use by HTML and the other
+// render helpers, so any template engine satisfying ExecuteTemplate can replace html/template.
+func (mx *Engine) HTMLEngine(engine ExecuteTemplate) {
+	mx.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := RouteContext(r.Context())
+			rctx.HTMLEngine = engine
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+// Text takes an ExecuteTemplate interface, such as a text/template, to handle
+// execution of the non-escaping templates used by TextTemplate.
+func (mx *Engine) Text(handler ExecuteTemplate) {
 	mx.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			rctx := RouteContext(r.Context())
-			rctx.HTMLEngine = handler
+			rctx.TextEngine = handler
 			next.ServeHTTP(w, r)
 		})
 	})
 }
 
+// TextGlob is like Engine.HTMLGlob but parses the files with text/template, which doesn't escape its output, and
+// injects the templates for use by TextTemplate. If the templates fail to parse the method will panic.
+func (mx *Engine) TextGlob(patterns ...string) {
+	var tmpl = texttemplate.New("")
+	for _, pattern := range patterns {
+		tmpl = texttemplate.Must(tmpl.ParseGlob(pattern))
+	}
+	mx.Text(tmpl)
+}
+
+// TextFs is like Engine.TextGlob but reads from the file system fs instead of the host operating system's file system.
+// If the templates fail to parse the method will panic.
+func (mx *Engine) TextFs(fs fs.FS, patterns ...string) {
+	mx.Text(texttemplate.Must(texttemplate.New("").ParseFS(fs, patterns...)))
+}
+
 // HTMLGlob parses the template definitions in the files identified by the patterns and calls Engine.Use
 // with middleware that injects the templates for use by HTML. If the templates fail to parse the method will panic.
 func (mx *Engine) HTMLGlob(patterns ...string) {
-	var tmpl = template.New("")
+	mx.HTMLGlobFuncs(nil, patterns...)
+}
+
+// HTMLGlobFuncs is like Engine.HTMLGlob but adds the functions in funcs to the templates before parsing them, so the
+// templates can call them.
+func (mx *Engine) HTMLGlobFuncs(funcs template.FuncMap, patterns ...string) {
+	if err := mx.htmlGlob(template.New("").Funcs(funcs), patterns); err != nil {
+		panic(err)
+	}
+}
+
+// HTMLGlobDelims is like Engine.HTMLGlob but parses the templates with the action delimiters left and right, such as
+// "[[" and "]]", instead of "{{" and "}}".
+func (mx *Engine) HTMLGlobDelims(left, right string, patterns ...string) {
+	if err := mx.htmlGlob(template.New("").Delims(left, right), patterns); err != nil {
+		panic(err)
+	}
+}
+
+// HTMLGlobE is like Engine.HTMLGlob but returns the error if the templates fail to parse instead of panicking, in
+// which case no templates are injected.
+func (mx *Engine) HTMLGlobE(patterns ...string) error {
+	return mx.htmlGlob(template.New(""), patterns)
+}
+
+func (mx *Engine) htmlGlob(tmpl *template.Template, patterns []string) error {
 	for _, pattern := range patterns {
-		tmpl = template.Must(tmpl.ParseGlob(pattern))
+		var err error
+		if tmpl, err = tmpl.ParseGlob(pattern); err != nil {
+			return err
+		}
 	}
+	mx.useHTMLSet(newHTMLSet(tmpl))
+	return nil
+}
+
+func (mx *Engine) useHTMLSet(set *htmlSet) {
 	mx.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			rctx := RouteContext(r.Context())
-			rctx.HTMLEngine = tmpl
+			rctx.HTMLEngine = set
 			next.ServeHTTP(w, r)
 		})
 	})
@@ -137,12 +246,13 @@ func (mx *Engine) HTMLGlob(patterns ...string) {
 // with middleware that injects the templates for use by HTML but will reload and parse the templates with each
 // request if reload is set to true. If the templates fail to parse the method will panic.
 func (mx *Engine) HTMLGlobReloadable(reload bool, patterns ...string) {
-	var tmpl *template.Template
+	var set *htmlSet
 	loadFn := func() {
-		tmpl = template.New("")
+		tmpl := template.New("")
 		for _, pattern := range patterns {
 			tmpl = template.Must(tmpl.ParseGlob(pattern))
 		}
+		set = newHTMLSet(tmpl)
 	}
 	loadFn()
 	mx.Use(func(next http.Handler) http.Handler {
@@ -151,35 +261,141 @@ func (mx *Engine) HTMLGlobReloadable(reload bool, patterns ...string) {
 				loadFn()
 			}
 			rctx := RouteContext(r.Context())
-			rctx.HTMLEngine = tmpl
+			rctx.HTMLEngine = set
 			next.ServeHTTP(w, r)
 		})
 	})
 }
 
-// HTMLFs is like Engine.HTMLGlob but reads from the file system fs instead of the host operating system's file system.
-// It accepts a list of glob patterns (Note that most file names serve as glob patterns matching only themselves.) and
-// will be injected into each request for use by HTML. If the templates fail to parse the method will panic.
-func (mx *Engine) HTMLFs(fs fs.FS, patterns ...string) {
-	tmpl := template.Must(template.ParseFS(fs, patterns...))
+// HTMLGlobWatch is like Engine.HTMLGlob but watches the directories of the patterns and reparses the templates only
+// when a matching file changes, which suits development better than Engine.HTMLGlobReloadable reparsing them on every
+// request. If the templates fail to parse after a change the error is logged and the previous templates keep being
+// served. The method panics if the templates fail to parse initially or their directories can't be watched. The
+// directories are watched for the lifetime of the process, see Engine.HTMLGlobWatchContext to stop watching them.
+func (mx *Engine) HTMLGlobWatch(patterns ...string) {
+	mx.HTMLGlobWatchContext(context.Background(), patterns...)
+}
+
+// HTMLGlobWatchContext is like Engine.HTMLGlobWatch but stops watching the directories once ctx is done, after which
+// the last templates keep being served.
+func (mx *Engine) HTMLGlobWatchContext(ctx context.Context, patterns ...string) {
+	hw := newHTMLWatcher(ctx, patterns)
 	mx.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			rctx := RouteContext(r.Context())
-			rctx.HTMLEngine = tmpl
+			rctx.HTMLEngine = hw.current()
 			next.ServeHTTP(w, r)
 		})
 	})
 }
 
+// HTMLFs is like Engine.HTMLGlob but reads from the file system fs instead of the host operating system's file system.
+// It accepts a list of glob patterns (Note that most file names serve as glob patterns matching only themselves.) and
+// will be injected into each request for use by HTML. If the templates fail to parse the method will panic.
+func (mx *Engine) HTMLFs(fs fs.FS, patterns ...string) {
+	mx.HTMLFsFuncs(nil, fs, patterns...)
+}
+
+// HTMLFsFuncs is like Engine.HTMLFs but adds the functions in funcs to the templates before parsing them, so the
+// templates can call them.
+func (mx *Engine) HTMLFsFuncs(funcs template.FuncMap, fs fs.FS, patterns ...string) {
+	mx.useHTMLSet(newHTMLSet(template.Must(template.New("").Funcs(funcs).ParseFS(fs, patterns...))))
+}
+
+// HTMLFsDelims is like Engine.HTMLFs but parses the templates with the action delimiters left and right, such as "[["
+// and "]]", instead of "{{" and "}}".
+func (mx *Engine) HTMLFsDelims(left, right string, fs fs.FS, patterns ...string) {
+	mx.useHTMLSet(newHTMLSet(template.Must(template.New("").Delims(left, right).ParseFS(fs, patterns...))))
+}
+
+// HTMLFsE is like Engine.HTMLFs but returns the error if the templates fail to parse instead of panicking, in which
+// case no templates are injected.
+func (mx *Engine) HTMLFsE(fs fs.FS, patterns ...string) error {
+	tmpl, err := template.New("").ParseFS(fs, patterns...)
+	if err != nil {
+		return err
+	}
+	mx.useHTMLSet(newHTMLSet(tmpl))
+	return nil
+}
+
 // Static adds a handler using http.FileSystem that serves HTTP requests with the contents of the file system rooted at rootPath.
+// The files are served under the /static prefix, see Engine.StaticAt.
 func (mx *Engine) Static(rootPath string) {
-	mx.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir(rootPath))))
+	mx.StaticAt("/static", rootPath)
 }
 
 // StaticFS adds a handler using http.FileSystem that serves HTTP requests with the contents of the file system rooted at rootPath.
-// fs is converted to a FileSystem implementation, for use with the FileServer.
+// fs is converted to a FileSystem implementation, for use with the FileServer. The files are served under the /static
+// prefix, see Engine.StaticFSAt.
 func (mx *Engine) StaticFS(fs fs.FS) {
-	mx.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.FS(fs))))
+	mx.StaticFSAt("/static", fs)
+}
+
+// StaticAt is like Engine.Static but serves the files under `urlPrefix`, such as /assets. It panics if the prefix doesn't
+// begin with '/'.
+func (mx *Engine) StaticAt(urlPrefix, rootPath string) {
+	mx.staticAt(urlPrefix, http.Dir(rootPath))
+}
+
+// StaticFSAt is like Engine.StaticFS but serves the files under `urlPrefix`, such as /assets. It panics if the prefix
+// doesn't begin with '/'.
+func (mx *Engine) StaticFSAt(urlPrefix string, fs fs.FS) {
+	mx.staticAt(urlPrefix, http.FS(fs))
+}
+
+func (mx *Engine) staticAt(urlPrefix string, root http.FileSystem) {
+	if !strings.HasPrefix(urlPrefix, "/") {
+		panic(fmt.Sprintf("penguin: static url prefix must begin with '/' in '%s'", urlPrefix))
+	}
+	prefix := strings.TrimSuffix(urlPrefix, "/") + "/"
+	mx.Handle(prefix+"*", http.StripPrefix(prefix, http.FileServer(root)))
+}
+
+// StaticFile registers a GET handler on `pattern` serving the single file at
+// filePath, such as /robots.txt or /favicon.ico, with a Content-Type guessed
+// from its extension. Missing files are answered by the Engine's NotFound
+// handler.
+func (mx *Engine) StaticFile(pattern, filePath string) {
+	mx.Get(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if fi, err := os.Stat(filePath); err != nil || fi.IsDir() {
+			mx.NotFoundHandler().ServeHTTP(w, r)
+			return
+		}
+		http.ServeFile(w, r, filePath)
+	})
+}
+
+// StaticFileFS is like Engine.StaticFile but serves the file `name` from fsys,
+// such as an embed.FS.
+func (mx *Engine) StaticFileFS(pattern string, fsys fs.FS, name string) {
+	mx.Get(pattern, func(w http.ResponseWriter, r *http.Request) {
+		serveFileFS(w, r, fsys, name, mx.NotFoundHandler())
+	})
+}
+
+// MountSPA mounts a single-page application served from fsys along `pattern`.
+// Requests for files that exist in fsys, relative to the mount point, are served
+// as static files and any other path under `pattern` is answered with the
+// index.html file, so the client-side router can take over deep links. Missing
+// static assets, such as a .js or .css file, are answered with a 404.
+func (mx *Engine) MountSPA(pattern string, fsys fs.FS) {
+	mx.Mount(pattern, spaHandler(fsys, "index.html", mx.NotFoundHandler))
+}
+
+// StaticSPA is like Engine.MountSPA but serves the single-page application from
+// the directory rootPath under `urlPrefix`, falling back to `indexFile`.
+func (mx *Engine) StaticSPA(urlPrefix, rootPath, indexFile string) {
+	mx.StaticSPAFS(urlPrefix, os.DirFS(rootPath), indexFile)
+}
+
+// StaticSPAFS is like Engine.StaticSPA but serves the single-page application
+// from fsys, such as an embed.FS.
+func (mx *Engine) StaticSPAFS(urlPrefix string, fsys fs.FS, indexFile string) {
+	if !strings.HasPrefix(urlPrefix, "/") {
+		panic(fmt.Sprintf("penguin: static url prefix must begin with '/' in '%s'", urlPrefix))
+	}
+	mx.Mount(urlPrefix, spaHandler(fsys, indexFile, mx.NotFoundHandler))
 }
 
 // HTMLFsReloadable is like Engine.HTMLGlob but reads from the file system fs instead of the host operating system's file system.
@@ -187,9 +403,9 @@ func (mx *Engine) StaticFS(fs fs.FS) {
 // will be injected into each request for use by HTML. The templates will be reloaded and parsed on each
 // request when reload is set to true. If the templates fail to parse the method will panic.
 func (mx *Engine) HTMLFsReloadable(reload bool, fs fs.FS, patterns ...string) {
-	var tmpl *template.Template
+	var set *htmlSet
 	loadFn := func() {
-		tmpl = template.Must(template.ParseFS(fs, patterns...))
+		set = newHTMLSet(template.Must(template.ParseFS(fs, patterns...)))
 	}
 	loadFn()
 	mx.Use(func(next http.Handler) http.Handler {
@@ -198,7 +414,7 @@ func (mx *Engine) HTMLFsReloadable(reload bool, fs fs.FS, patterns ...string) {
 				loadFn()
 			}
 			rctx := RouteContext(r.Context())
-			rctx.HTMLEngine = tmpl
+			rctx.HTMLEngine = set
 			next.ServeHTTP(w, r)
 		})
 	})
@@ -233,61 +449,71 @@ func (mx *Engine) MethodFunc(method, pattern string, handlerFn http.HandlerFunc)
 }
 
 // Connect adds the route `pattern` that matches a CONNECT http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Connect(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mCONNECT, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Connect(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mCONNECT, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Delete adds the route `pattern` that matches a DELETE http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Delete(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mDELETE, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Delete(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mDELETE, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Get adds the route `pattern` that matches a GET http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Get(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mGET, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Get(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mGET, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Head adds the route `pattern` that matches a HEAD http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Head(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mHEAD, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Head(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mHEAD, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Options adds the route `pattern` that matches a OPTIONS http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Options(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mOPTIONS, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Options(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mOPTIONS, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Patch adds the route `pattern` that matches a PATCH http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Patch(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mPATCH, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Patch(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mPATCH, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Post adds the route `pattern` that matches a POST http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Post(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mPOST, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Post(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mPOST, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Put adds the route `pattern` that matches a PUT http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Put(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mPUT, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Put(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mPUT, pattern, inlineChain(handlerFn, middlewares))
 }
 
 // Trace adds the route `pattern` that matches a TRACE http method to
-// execute the `handlerFn` http.HandlerFunc.
-func (mx *Engine) Trace(pattern string, handlerFn http.HandlerFunc) {
-	mx.handle(mTRACE, pattern, handlerFn)
+// execute the `handlerFn` http.HandlerFunc, wrapped by any inline `middlewares`.
+func (mx *Engine) Trace(pattern string, handlerFn http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) {
+	mx.handle(mTRACE, pattern, inlineChain(handlerFn, middlewares))
+}
+
+// inlineChain wraps `handlerFn` with the inline `middlewares` of a single
+// route, which run after the middleware stack of the router.
+func inlineChain(handlerFn http.HandlerFunc, middlewares []func(http.Handler) http.Handler) http.Handler {
+	if len(middlewares) == 0 {
+		return handlerFn
+	}
+	return Chain(middlewares...).HandlerFunc(handlerFn)
 }
 
 // NotFound sets a custom http.HandlerFunc for routing paths that could
-// not be found. The default 404 handler is `http.NotFound`.
+// not be found. The default 404 handler is `http.NotFound`, with the request
+// ID echoed in the RequestIDHeader response header.
 func (mx *Engine) NotFound(handlerFn http.HandlerFunc) {
 	// Build NotFound handler chain
 	m := mx
@@ -306,8 +532,51 @@ func (mx *Engine) NotFound(handlerFn http.HandlerFunc) {
 	})
 }
 
+// NotFoundTemplate sets a 404 handler rendering the template `name` with `v`, see HTML, through the template engine
+// assigned to the request context. Requests without an engine, or whose template fails to execute, are answered by
+// the default 404 handler.
+func (mx *Engine) NotFoundTemplate(name string, v any) {
+	mx.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		setRequestIDHeader(w, r)
+		if HTMLEngineFromCtx(r.Context()) == nil || HTMLErr(w, r, http.StatusNotFound, name, v) != nil {
+			notFoundHandler(w, r)
+		}
+	})
+}
+
+// MethodNotAllowedTemplate is like Engine.NotFoundTemplate but sets the 405 handler, which keeps the Allow header of
+// the default handler. Clients accepting JSON still get the JSON body of the default handler.
+func (mx *Engine) MethodNotAllowedTemplate(name string, v any) {
+	mx.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		if IsJSON(r) || HTMLEngineFromCtx(r.Context()) == nil {
+			methodNotAllowedHandler(w, r)
+			return
+		}
+		setRequestIDHeader(w, r)
+		if rctx := RouteContext(r.Context()); rctx != nil && len(rctx.methodsAllowed) > 0 {
+			w.Header().Set("Allow", strings.Join(rctx.AllowedMethods(), ", "))
+		}
+		if HTMLErr(w, r, http.StatusMethodNotAllowed, name, v) != nil {
+			methodNotAllowedHandler(w, r)
+		}
+	})
+}
+
+// Handle404Fallthrough sets `h` to serve every request that matches no route,
+// including those of mounted sub-routers, such as a reverse proxy to a legacy
+// service. Unlike a handler set with NotFound, `h` is served the request
+// without the routing context, so it sees the original r.URL.Path and any
+// penguin router it is built on routes the full path afresh, rather than the
+// path shifted past a mount.
+func (mx *Engine) Handle404Fallthrough(h http.Handler) {
+	mx.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), RouteCtxKey, (*Context)(nil))))
+	})
+}
+
 // MethodNotAllowed sets a custom http.HandlerFunc for routing paths where the
-// method is unresolved. The default handler returns a 405 with an empty body.
+// method is unresolved. The default handler returns a 405 with an empty body
+// and the request ID echoed in the RequestIDHeader response header.
 func (mx *Engine) MethodNotAllowed(handlerFn http.HandlerFunc) {
 	// Build MethodNotAllowed handler chain
 	m := mx
@@ -326,6 +595,55 @@ func (mx *Engine) MethodNotAllowed(handlerFn http.HandlerFunc) {
 	})
 }
 
+// AutoOptions enables or disables automatic responses to OPTIONS requests for
+// routes without an OPTIONS handler. When enabled, such a request is answered
+// with a 204 and an Allow header listing the methods registered on the route,
+// rather than a 405. Explicitly registered OPTIONS handlers always win, and
+// the setting applies to mounted sub-routers too.
+func (mx *Engine) AutoOptions(enabled bool) {
+	m := mx.base()
+	m.autoOptions = enabled
+	m.updateSubRoutes(func(subMux *Engine) {
+		subMux.AutoOptions(enabled)
+	})
+}
+
+// AutoHead enables or disables serving HEAD requests for routes without a HEAD
+// handler with their GET handler. The GET handler runs as usual, so the status
+// and headers match the GET response, but the body it writes is discarded.
+// Explicitly registered HEAD handlers always win, and the setting applies to
+// mounted sub-routers too. See also middleware.GetHead.
+func (mx *Engine) AutoHead(enabled bool) {
+	m := mx.base()
+	m.autoHead = enabled
+	m.updateSubRoutes(func(subMux *Engine) {
+		subMux.AutoHead(enabled)
+	})
+}
+
+// RedirectTrailingSlash enables or disables redirecting requests that match no
+// route, but would with a trailing slash added or removed, to that canonical
+// path. GET and HEAD requests are redirected with a 301, other methods with a
+// 308 so the method and body are kept. A path with a handler for both variants
+// is never redirected, and the setting applies to mounted sub-routers too.
+func (mx *Engine) RedirectTrailingSlash(enabled bool) {
+	m := mx.base()
+	m.redirectTrailingSlash = enabled
+	m.updateSubRoutes(func(subMux *Engine) {
+		subMux.RedirectTrailingSlash(enabled)
+	})
+}
+
+// base returns the Engine owning the routing tree, which is the first non
+// inline mux up from mx.
+func (mx *Engine) base() *Engine {
+	m := mx
+	for m.inline && m.parent != nil {
+		m = m.parent
+	}
+	return m
+}
+
 // With adds inline middlewares for an endpoint handler.
 func (mx *Engine) With(middlewares ...func(http.Handler) http.Handler) Router {
 	// Similarly as in handle(), we must build the mux handler once additional
@@ -334,22 +652,48 @@ func (mx *Engine) With(middlewares ...func(http.Handler) http.Handler) Router {
 		mx.updateRouteHandler()
 	}
 
-	// Copy middlewares from parent inline muxs
+	// Copy middlewares from parent inline muxs into a slice sized up front. When
+	// there are no additional middlewares the parent stack is shared instead,
+	// capped at its length so a later Use on either mux can't overwrite the
+	// other's stack.
 	var mws Middlewares
 	if mx.inline {
-		mws = make(Middlewares, len(mx.middlewares))
-		copy(mws, mx.middlewares)
+		mws = mx.middlewares[:len(mx.middlewares):len(mx.middlewares)]
+	}
+	if len(middlewares) > 0 {
+		stack := make(Middlewares, len(mws), len(mws)+len(middlewares))
+		copy(stack, mws)
+		mws = append(stack, middlewares...)
 	}
-	mws = append(mws, middlewares...)
 
 	im := &Engine{
-		pool: mx.pool, inline: true, parent: mx, tree: mx.tree, middlewares: mws,
+		pool: mx.pool, inline: true, parent: mx, tree: mx.tree, mu: mx.mu, names: mx.names, middlewares: mws,
 		notFoundHandler: mx.notFoundHandler, methodNotAllowedHandler: mx.methodNotAllowedHandler,
 	}
 
 	return im
 }
 
+// WithTimeout is like Engine.With but returns an inline-Engine whose routes are
+// served with a request context that is cancelled after `d`, for giving a
+// group of endpoints a shorter deadline than the server's:
+//
+//	r.WithTimeout(2 * time.Second).Group(func(r penguin.Router) {
+//		r.Get("/reports", buildReport)
+//	})
+//
+// Handlers must watch the context for the deadline to have any effect, see
+// the Timeout middleware for one that also writes a 504 response.
+func (mx *Engine) WithTimeout(d time.Duration) Router {
+	return mx.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+}
+
 // Group creates a new inline-Engine with a fresh middleware stack. It's useful
 // for a group of handlers along the same routing path that use an additional
 // set of middlewares. See _examples/.
@@ -400,6 +744,15 @@ func (mx *Engine) Mount(pattern string, handler http.Handler) {
 	if ok && subr.methodNotAllowedHandler == nil && mx.methodNotAllowedHandler != nil {
 		subr.MethodNotAllowed(mx.methodNotAllowedHandler)
 	}
+	if ok && mx.base().autoOptions {
+		subr.AutoOptions(true)
+	}
+	if ok && mx.base().autoHead {
+		subr.AutoHead(true)
+	}
+	if ok && mx.base().redirectTrailingSlash {
+		subr.RedirectTrailingSlash(true)
+	}
 
 	mountHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		rctx := RouteContext(r.Context())
@@ -435,19 +788,342 @@ func (mx *Engine) Mount(pattern string, handler http.Handler) {
 }
 
 // Controller is a shorthand for Router.Route("/pattern", (&HomeController{}).Router)
-// and makes it clearer that a controller is being used at a glance
+// and makes it clearer that a controller is being used at a glance. If `c`
+// implements ControllerMiddleware its middlewares are used by the sub-router
+// before calling c.Router.
+//
+// If `c` implements Initializer, Init is called first and the method panics if
+// it fails. See Engine.ControllerE.
 func (mx *Engine) Controller(pattern string, c Controller) {
+	if err := mx.ControllerE(pattern, c); err != nil {
+		panic(err)
+	}
+}
+
+// ControllerE is like Engine.Controller but returns the error if the Init
+// method of the controller fails, in which case no routes are registered.
+func (mx *Engine) ControllerE(pattern string, c Controller) error {
+	if err := initController(c); err != nil {
+		return fmt.Errorf("penguin: failed to initialize the controller on '%s': %w", pattern, err)
+	}
 	mx.Route(pattern, func(r Router) {
+		useControllerMiddleware(r, c)
 		c.Router(r)
 	})
+	return nil
+}
+
+// initController calls the Init method of `c` when it implements Initializer.
+func initController(c any) error {
+	if i, ok := c.(Initializer); ok {
+		return i.Init()
+	}
+	return nil
+}
+
+// Resource mounts the conventional RESTful routes of the actions implemented
+// by `c` along the `pattern`. See ResourceController. Like Engine.Controller,
+// it panics if the Init method of an Initializer fails. It also panics if `c`
+// implements neither an action nor Controller, such as when an action has the
+// wrong signature, since it would register no routes.
+func (mx *Engine) Resource(pattern string, c ResourceController) {
+	if _, ok := c.(Controller); !ok && !hasResourceAction(c) {
+		panic(fmt.Sprintf("penguin: the resource on '%s' implements no action or Router method", pattern))
+	}
+	if err := initController(c); err != nil {
+		panic(fmt.Errorf("penguin: failed to initialize the resource on '%s': %w", pattern, err))
+	}
+	mx.Route(pattern, func(r Router) {
+		useControllerMiddleware(r, c)
+		if ctrl, ok := c.(Controller); ok {
+			ctrl.Router(r)
+		}
+		if a, ok := c.(interface{ Index(http.ResponseWriter, *http.Request) }); ok {
+			r.Get("/", a.Index)
+		}
+		if a, ok := c.(interface{ New(http.ResponseWriter, *http.Request) }); ok {
+			r.Get("/new", a.New)
+		}
+		if a, ok := c.(interface{ Create(http.ResponseWriter, *http.Request) }); ok {
+			r.Post("/", a.Create)
+		}
+		if a, ok := c.(interface{ Show(http.ResponseWriter, *http.Request) }); ok {
+			r.Get("/{id}", a.Show)
+		}
+		if a, ok := c.(interface{ Edit(http.ResponseWriter, *http.Request) }); ok {
+			r.Get("/{id}/edit", a.Edit)
+		}
+		if a, ok := c.(interface{ Update(http.ResponseWriter, *http.Request) }); ok {
+			r.Put("/{id}", a.Update)
+			r.Patch("/{id}", a.Update)
+		}
+		if a, ok := c.(interface{ Delete(http.ResponseWriter, *http.Request) }); ok {
+			r.Delete("/{id}", a.Delete)
+		}
+	})
+}
+
+// hasResourceAction reports whether `c` implements any of the actions of a
+// ResourceController.
+func hasResourceAction(c ResourceController) bool {
+	switch c.(type) {
+	case interface{ Index(http.ResponseWriter, *http.Request) },
+		interface{ New(http.ResponseWriter, *http.Request) },
+		interface{ Create(http.ResponseWriter, *http.Request) },
+		interface{ Show(http.ResponseWriter, *http.Request) },
+		interface{ Edit(http.ResponseWriter, *http.Request) },
+		interface{ Update(http.ResponseWriter, *http.Request) },
+		interface{ Delete(http.ResponseWriter, *http.Request) }:
+		return true
+	}
+	return false
+}
+
+// useControllerMiddleware uses the middlewares of `c` on `r` when it
+// implements ControllerMiddleware.
+func useControllerMiddleware(r Router, c any) {
+	if cm, ok := c.(ControllerMiddleware); ok {
+		r.Use(cm.Middlewares()...)
+	}
+}
+
+// ErrRouteNotFound is wrapped by the error of RemoveRoute when no route is
+// registered for the method and pattern.
+var ErrRouteNotFound = errors.New("penguin: route not found")
+
+// RemoveRoute deletes the endpoint registered for the `method` http method on
+// the exact routing `pattern`. Any nodes left empty by the removal are pruned
+// from the tree. It is safe to call while the Engine is serving requests.
+//
+// The returned error wraps ErrRouteNotFound if there was nothing to remove,
+// so callers only interested in whether a route was removed can test for it
+// with errors.Is.
+func (mx *Engine) RemoveRoute(method, pattern string) error {
+	m, ok := methodMap[strings.ToUpper(method)]
+	if !ok {
+		return fmt.Errorf("penguin: '%s' http method is not supported", method)
+	}
+
+	mx.mu.Lock()
+	defer mx.mu.Unlock()
+
+	// The pattern of a route with an optional segment is held by two endpoints.
+	removed := false
+	for mx.tree.RemoveRoute(m, pattern) {
+		removed = true
+	}
+	if !removed {
+		return fmt.Errorf("%w: %s %s", ErrRouteNotFound, method, pattern)
+	}
+	return nil
+}
+
+// HasRoute reports whether a route is registered for the `method` http method
+// on the exact routing `pattern`, such as "/users/{id}", without matching it
+// as a request path. Routes of mounted sub-routers aren't searched.
+func (mx *Engine) HasRoute(method, pattern string) bool {
+	m, ok := methodMap[strings.ToUpper(method)]
+	if !ok {
+		return false
+	}
+
+	mx.mu.RLock()
+	defer mx.mu.RUnlock()
+	return mx.tree.HasRoute(m, pattern)
 }
 
 // Routes returns a slice of routing information from the tree,
 // useful for traversing available routes of a router.
 func (mx *Engine) Routes() []Route {
+	mx.mu.RLock()
+	defer mx.mu.RUnlock()
 	return mx.tree.routes()
 }
 
+// PrintRoutes writes a table of the routes of the Engine, including those of
+// mounted sub-routers, to `w` for debugging. Each line holds the method, the
+// routing pattern, the name of the handler and the number of middlewares the
+// route is served through, sorted like WalkSorted.
+func (mx *Engine) PrintRoutes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tHANDLER\tMIDDLEWARES")
+	_ = WalkSorted(mx, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", method, route, handlerName(handler), len(middlewares))
+		return nil
+	})
+	tw.Flush()
+}
+
+// RouteInfo describes a route in the JSON served by Engine.RoutesHandler.
+type RouteInfo struct {
+	Method    string `json:"method"`
+	Pattern   string `json:"pattern"`
+	SubRouter bool   `json:"subrouter"`
+}
+
+// RoutesHandler returns a handler responding with the routes of the Engine as
+// a JSON array of RouteInfo, sorted by pattern and method, for API discovery
+// tooling. Mounted sub-routers are flattened into their routes with the full
+// patterns, which are flagged as served by a sub-router.
+//
+//	r.Get("/_routes", r.RoutesHandler())
+func (mx *Engine) RoutesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only the routes registered on the Engine itself keep their pattern
+		// in its routes, the others are walked from mounted sub-routers.
+		own := map[string]bool{}
+		for _, route := range mx.Routes() {
+			if route.SubRoutes == nil {
+				own[route.Pattern] = true
+			}
+		}
+
+		routes := []RouteInfo{}
+		_ = WalkSorted(mx, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+			routes = append(routes, RouteInfo{Method: method, Pattern: route, SubRouter: !own[route]})
+			return nil
+		})
+		_ = JSON(w, r, http.StatusOK, routes)
+	}
+}
+
+// handlerName returns the function name of a http.HandlerFunc, or the type
+// name of any other http.Handler.
+func handlerName(h http.Handler) string {
+	if fn, ok := h.(http.HandlerFunc); ok {
+		if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// SubRouters returns the sub-routers mounted directly on this router, keyed by
+// their mount pattern. Sub-routers nested deeper can be reached by calling
+// SubRouters on the returned values that are an *Engine.
+func (mx *Engine) SubRouters() map[string]Routes {
+	subRouters := make(map[string]Routes)
+	for _, r := range mx.Routes() {
+		if r.SubRoutes == nil {
+			continue
+		}
+		pattern := strings.TrimSuffix(r.Pattern, "/*")
+		if pattern == "" {
+			pattern = "/"
+		}
+		subRouters[pattern] = r.SubRoutes
+	}
+	return subRouters
+}
+
+// Name assigns `name` to the routing `pattern` of the Engine so its URL can be
+// built with Engine.URL, for example:
+//
+//	r.Get("/users/{id}", showUser)
+//	r.Name("user.show", "/users/{id}")
+//
+// Names must be unique across the router and its mounted sub-routers, as the
+// first route found with a name wins.
+func (mx *Engine) Name(name, pattern string) {
+	mx.mu.Lock()
+	defer mx.mu.Unlock()
+	mx.names[name] = pattern
+}
+
+// URL returns the path of the route named `name`, see Engine.Name, with its
+// parameters replaced by `params`. Named routes of the mounted sub-routers are
+// found too and prefixed with their mount pattern. It returns an error if the
+// name is unknown, a parameter is missing or doesn't match the regexp of its
+// placeholder.
+func (mx *Engine) URL(name string, params map[string]string) (string, error) {
+	pattern, ok := mx.lookupName(name)
+	if !ok {
+		return "", fmt.Errorf("penguin: unknown route name '%s'", name)
+	}
+	return buildURL(name, pattern, params)
+}
+
+// lookupName returns the full pattern of the route named `name` on the Engine
+// or its mounted sub-routers.
+func (mx *Engine) lookupName(name string) (string, bool) {
+	mx.mu.RLock()
+	pattern, ok := mx.names[name]
+	mx.mu.RUnlock()
+	if ok {
+		return pattern, true
+	}
+
+	for prefix, routes := range mx.SubRouters() {
+		subr, ok := routes.(*Engine)
+		if !ok {
+			continue
+		}
+		if pattern, ok := subr.lookupName(name); ok {
+			return strings.TrimSuffix(prefix, "/") + pattern, true
+		}
+	}
+	return "", false
+}
+
+// buildURL replaces the {param} placeholders and trailing wildcard of the
+// routing `pattern` with `params`.
+func buildURL(name, pattern string, params map[string]string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '{':
+			// find the matching closing brace, regexps may have their own
+			depth, end := 1, i+1
+			for ; end < len(pattern) && depth > 0; end++ {
+				switch pattern[end] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			key, rexpat, isRegexp := strings.Cut(pattern[i+1:end-1], ":")
+			key, optional := strings.CutSuffix(key, "?")
+			value, ok := params[key]
+			if (!ok || value == "") && optional {
+				// drop the whole optional segment, along with its slash
+				prefix := strings.TrimSuffix(b.String(), "/")
+				b.Reset()
+				b.WriteString(prefix)
+				i = end - 1
+				continue
+			}
+			if !ok || value == "" {
+				return "", fmt.Errorf("penguin: missing url param '%s' for route '%s'", key, name)
+			}
+			if isRegexp {
+				paramTypesMu.RLock()
+				if rex, ok := paramTypes[rexpat]; ok {
+					rexpat = rex
+				}
+				paramTypesMu.RUnlock()
+				rex, err := regexp.Compile("^(?:" + rexpat + ")$")
+				if err != nil {
+					return "", fmt.Errorf("penguin: invalid regexp in route '%s': %w", name, err)
+				}
+				if !rex.MatchString(value) {
+					return "", fmt.Errorf("penguin: url param '%s' for route '%s' doesn't match '%s'", key, name, rexpat)
+				}
+			}
+			b.WriteString(url.PathEscape(value))
+			i = end - 1
+		case c == '*' && i == len(pattern)-1:
+			b.WriteString(params["*"])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if b.Len() == 0 {
+		return "/", nil
+	}
+	return b.String(), nil
+}
+
 // Middlewares returns a slice of middleware handler functions.
 func (mx *Engine) Middlewares() Middlewares {
 	return mx.middlewares
@@ -465,7 +1141,9 @@ func (mx *Engine) Match(rctx *Context, method, path string) bool {
 		return false
 	}
 
+	mx.mu.RLock()
 	node, _, h := mx.tree.FindRoute(rctx, m, path)
+	mx.mu.RUnlock()
 
 	if node != nil && node.subroutes != nil {
 		rctx.RoutePath = mx.nextRoutePath(rctx)
@@ -475,13 +1153,29 @@ func (mx *Engine) Match(rctx *Context, method, path string) bool {
 	return h != nil
 }
 
+// MatchPattern is like Engine.Match but also returns the full routing pattern
+// of the matched route, including the patterns of any sub-routers it is
+// mounted under, such as "/api/users/{id}".
+//
+// Note: the *Context state is updated during execution, so manage
+// the state carefully or make a NewRouteContext().
+func (mx *Engine) MatchPattern(rctx *Context, method, path string) (string, bool) {
+	if !mx.Match(rctx, method, path) {
+		return "", false
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern, true
+	}
+	return "/", true
+}
+
 // NotFoundHandler returns the default Engine 404 responder whenever a route
 // cannot be found.
 func (mx *Engine) NotFoundHandler() http.HandlerFunc {
 	if mx.notFoundHandler != nil {
 		return mx.notFoundHandler
 	}
-	return http.NotFound
+	return notFoundHandler
 }
 
 // MethodNotAllowedHandler returns the default Engine 405 responder whenever
@@ -508,13 +1202,20 @@ func (mx *Engine) handle(method methodTyp, pattern string, handler http.Handler)
 	// Build endpoint handler with inline middlewares for the route
 	var h http.Handler
 	if mx.inline {
-		mx.handler = http.HandlerFunc(mx.routeHTTP)
-		h = Chain(mx.middlewares...).Handler(handler)
+		if mx.handler == nil {
+			mx.handler = http.HandlerFunc(mx.routeHTTP)
+		}
+		h = handler
+		if len(mx.middlewares) > 0 {
+			h = Chain(mx.middlewares...).Handler(handler)
+		}
 	} else {
 		h = handler
 	}
 
 	// Add the endpoint to the tree and return the node
+	mx.mu.Lock()
+	defer mx.mu.Unlock()
 	return mx.tree.InsertRoute(method, pattern, h)
 }
 
@@ -548,17 +1249,64 @@ func (mx *Engine) routeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find the route
-	if _, _, h := mx.tree.FindRoute(rctx, method, routePath); h != nil {
+	mx.mu.RLock()
+	_, _, h := mx.tree.FindRoute(rctx, method, routePath)
+	mx.mu.RUnlock()
+	if h != nil {
 		h.ServeHTTP(w, r)
 		return
 	}
+	if rctx.methodNotAllowed && method == mHEAD && mx.autoHead && rctx.allowsMethod(mGET) {
+		mx.mu.RLock()
+		_, _, h = mx.tree.FindRoute(rctx, mGET, routePath)
+		mx.mu.RUnlock()
+		if h != nil {
+			rctx.methodNotAllowed = false
+			h.ServeHTTP(headResponseWriter{w}, r)
+			return
+		}
+	}
 	if rctx.methodNotAllowed {
+		if method == mOPTIONS && mx.autoOptions {
+			autoOptionsHandler(w, r)
+			return
+		}
 		mx.MethodNotAllowedHandler().ServeHTTP(w, r)
-	} else {
+	} else if !mx.redirectTrailingSlash || !mx.trailingSlashRedirect(w, r, routePath) {
 		mx.NotFoundHandler().ServeHTTP(w, r)
 	}
 }
 
+// trailingSlashRedirect redirects the request to its path with the trailing
+// slash added or removed if that path matches a route, reporting whether it
+// did.
+func (mx *Engine) trailingSlashRedirect(w http.ResponseWriter, r *http.Request, routePath string) bool {
+	path := r.URL.EscapedPath()
+	if strings.HasSuffix(routePath, "/") {
+		if routePath == "/" {
+			return false
+		}
+		routePath, path = strings.TrimSuffix(routePath, "/"), strings.TrimSuffix(path, "/")
+	} else {
+		routePath, path = routePath+"/", path+"/"
+	}
+
+	// A leading "//" would be a protocol relative redirect to another host.
+	if strings.HasPrefix(path, "//") || !mx.Match(NewRouteContext(), r.Method, routePath) {
+		return false
+	}
+
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+	code := http.StatusMovedPermanently
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+	http.Redirect(w, r, path, code)
+	return true
+}
+
 func (mx *Engine) nextRoutePath(rctx *Context) string {
 	routePath := "/"
 	nx := len(rctx.routeParams.Keys) - 1 // index of last param in list
@@ -587,9 +1335,134 @@ func (mx *Engine) updateRouteHandler() {
 	mx.handler = chain(mx.middlewares, http.HandlerFunc(mx.routeHTTP))
 }
 
+// notFoundHandler is a helper function to respond with a 404, not found.
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	setRequestIDHeader(w, r)
+	http.NotFound(w, r)
+}
+
 // methodNotAllowedHandler is a helper function to respond with a 405,
-// method not allowed.
+// method not allowed. The Allow header lists the methods registered on the
+// matched route, which are also sent in a JSON body to clients accepting JSON.
 func methodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	setRequestIDHeader(w, r)
+
+	allowed := []string{}
+	if rctx := RouteContext(r.Context()); rctx != nil {
+		allowed = append(allowed, rctx.AllowedMethods()...)
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+	}
+
+	if IsJSON(r) {
+		_ = JSON(w, r, 405, M{"error": "method not allowed", "allowed": allowed})
+		return
+	}
 	w.WriteHeader(405)
 	w.Write(nil)
 }
+
+// autoOptionsHandler is a helper function to respond to an OPTIONS request
+// with a 204 and the methods allowed on the matched route.
+func autoOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	allowed := []string{http.MethodOptions}
+	if rctx := RouteContext(r.Context()); rctx != nil {
+		allowed = append(allowed, rctx.AllowedMethods()...)
+	}
+	sort.Strings(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// headResponseWriter discards the body written by a GET handler serving a HEAD
+// request, keeping the headers and status.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Flush sends the headers of a streaming GET handler, such as one started with
+// NewEventStream, to the client.
+func (w headResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// spaAssetExts are the extensions of the static assets that are answered with a
+// 404 by spaHandler when missing, rather than the index file, so a stale
+// script or stylesheet reference fails loudly instead of loading HTML.
+var spaAssetExts = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true, ".json": true, ".wasm": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true, ".avif": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+}
+
+// spaHandler serves the files of fsys by their path relative to the routing
+// path of the request, falling back to the `index` file for any path that
+// doesn't name a file in fsys, except for missing static assets which are
+// answered by the handler returned by `notFound`.
+func spaHandler(fsys fs.FS, index string, notFound func() http.HandlerFunc) http.Handler {
+	fileServer := http.FileServer(http.FS(fsys))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routePath := r.URL.Path
+		if rctx := RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+			routePath = rctx.RoutePath
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+routePath), "/")
+		if name != "" && name != index {
+			if fi, err := fs.Stat(fsys, name); err == nil && !fi.IsDir() {
+				r2 := new(http.Request)
+				*r2 = *r
+				r2.URL = new(url.URL)
+				*r2.URL = *r.URL
+				r2.URL.Path = "/" + name
+				r2.URL.RawPath = ""
+				fileServer.ServeHTTP(w, r2)
+				return
+			}
+			if spaAssetExts[strings.ToLower(path.Ext(name))] {
+				notFound()(w, r)
+				return
+			}
+		}
+
+		serveFileFS(w, r, fsys, index, notFound())
+	})
+}
+
+// serveFileFS replies to the request with the contents of the file `name` in
+// fsys, or with the `notFound` handler if it's missing or a directory.
+func serveFileFS(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, notFound http.HandlerFunc) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err == nil && fi.IsDir() {
+		notFound(w, r)
+		return
+	}
+	rs, ok := f.(io.ReadSeeker)
+	if err != nil || !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, fi.ModTime(), rs)
+}
+
+// setRequestIDHeader writes the request ID, if there is one, to the
+// RequestIDHeader response header.
+func setRequestIDHeader(w http.ResponseWriter, r *http.Request) {
+	if reqID := RequestIDFromCtx(r.Context()); reqID != "" {
+		w.Header().Set(RequestIDHeader, reqID)
+	}
+}
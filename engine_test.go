@@ -3,14 +3,23 @@ package penguin
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -1694,6 +1703,1141 @@ func TestMuxMatch(t *testing.T) {
 	}
 }
 
+func TestMuxMatchPattern(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	r := New()
+	r.Get("/", h)
+	r.Get("/articles/{id}", h)
+	r.Route("/api", func(r Router) {
+		r.Route("/users", func(r Router) {
+			r.Get("/{id}", h)
+		})
+	})
+
+	tests := []struct {
+		method, path string
+		pattern      string
+		ok           bool
+	}{
+		{"GET", "/", "/", true},
+		{"GET", "/articles/10", "/articles/{id}", true},
+		{"GET", "/api/users/1", "/api/users/{id}", true},
+		{"POST", "/api/users/1", "", false},
+		{"GET", "/missing", "", false},
+	}
+	for _, tt := range tests {
+		pattern, ok := r.MatchPattern(NewRouteContext(), tt.method, tt.path)
+		if pattern != tt.pattern || ok != tt.ok {
+			t.Errorf("MatchPattern(%s %s) = %q, %v, expecting %q, %v", tt.method, tt.path, pattern, ok, tt.pattern, tt.ok)
+		}
+	}
+}
+
+func TestMuxRemoveRoute(t *testing.T) {
+	r := New()
+	r.Get("/hi", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("get hi"))
+	})
+	r.Post("/hi", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("post hi"))
+	})
+	r.Get("/plugins/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("plugin " + URLParam(r, "name")))
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	if _, body := testRequest(t, ts, "GET", "/plugins/x", nil); body != "plugin x" {
+		t.Fatalf(body)
+	}
+
+	if err := r.RemoveRoute("GET", "/plugins/{name}"); err != nil {
+		t.Fatal(err)
+	}
+	if resp, _ := testRequest(t, ts, "GET", "/plugins/x", nil); resp.StatusCode != 404 {
+		t.Fatalf("expecting 404 after removal, got %d", resp.StatusCode)
+	}
+
+	if err := r.RemoveRoute("GET", "/hi"); err != nil {
+		t.Fatal(err)
+	}
+	if resp, _ := testRequest(t, ts, "GET", "/hi", nil); resp.StatusCode != 405 {
+		t.Fatalf("expecting 405 after removal, got %d", resp.StatusCode)
+	}
+	if _, body := testRequest(t, ts, "POST", "/hi", nil); body != "post hi" {
+		t.Fatalf(body)
+	}
+
+	if err := r.RemoveRoute("GET", "/hi"); !errors.Is(err, ErrRouteNotFound) {
+		t.Fatalf("expecting ErrRouteNotFound when removing a missing route, got %v", err)
+	}
+	if err := r.RemoveRoute("DELETE", "/missing"); err == nil || err.Error() != "penguin: route not found: DELETE /missing" {
+		t.Fatalf("unexpected error removing a route that never existed: %v", err)
+	}
+	if err := r.RemoveRoute("BOGUS", "/hi"); err == nil || errors.Is(err, ErrRouteNotFound) {
+		t.Fatalf("expecting an unsupported method error, got %v", err)
+	}
+}
+
+func TestMuxHasRoute(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	r := New()
+	r.Get("/x", h)
+	r.Get("/users/{id}", h)
+	r.Put("/users/{id:[0-9]+}", h)
+	r.HandleFunc("/any", h)
+
+	tests := []struct {
+		method, pattern string
+		expected        bool
+	}{
+		{"GET", "/x", true},
+		{"get", "/x", true},
+		{"POST", "/x", false},
+		{"GET", "/x/", false},
+		{"GET", "/users/{id}", true},
+		{"GET", "/users/1", false},
+		{"GET", "/users/{name}", false},
+		{"PUT", "/users/{id:[0-9]+}", true},
+		{"PUT", "/users/{id}", false},
+		{"DELETE", "/any", true},
+		{"BOGUS", "/x", false},
+	}
+	for _, tt := range tests {
+		if r.HasRoute(tt.method, tt.pattern) != tt.expected {
+			t.Errorf("HasRoute(%s, %s) != %v", tt.method, tt.pattern, tt.expected)
+		}
+	}
+}
+
+func TestMuxOptionalSegmentRoute(t *testing.T) {
+	r := New()
+	r.Get("/files/{name?}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RoutePattern(r.Context())))
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, path := range []string{"/files", "/files/report.pdf"} {
+		if _, body := testRequest(t, ts, "GET", path, nil); body != "/files/{name?}" {
+			t.Fatalf("%s: expecting the declared pattern, got %q", path, body)
+		}
+	}
+
+	routes := r.Routes()
+	if len(routes) != 1 || routes[0].Pattern != "/files/{name?}" || routes[0].Handlers["GET"] == nil {
+		t.Fatalf("expecting a single /files/{name?} route, got %v", routes)
+	}
+	if !r.HasRoute("GET", "/files/{name?}") {
+		t.Fatal("expecting HasRoute to find the declared pattern")
+	}
+	if r.HasRoute("GET", "/files") || r.HasRoute("GET", "/files/{name}") {
+		t.Fatal("expecting HasRoute not to find the expanded patterns")
+	}
+
+	if err := r.RemoveRoute("GET", "/files/{name?}"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/files", "/files/report.pdf"} {
+		if resp, _ := testRequest(t, ts, "GET", path, nil); resp.StatusCode != 404 {
+			t.Fatalf("%s: expecting 404 after removal, got %d", path, resp.StatusCode)
+		}
+	}
+	if r.HasRoute("GET", "/files/{name?}") {
+		t.Fatal("expecting the route to be gone after removal")
+	}
+}
+
+func printRoutesHandler(w http.ResponseWriter, r *http.Request) {}
+
+func TestMuxPrintRoutes(t *testing.T) {
+	r := New()
+	r.Use(func(next http.Handler) http.Handler { return next })
+	r.Get("/users", printRoutesHandler)
+	r.Route("/api", func(r Router) {
+		r.With(func(next http.Handler) http.Handler { return next }).Post("/items", printRoutesHandler)
+	})
+	r.Handle("/files", http.FileServer(http.Dir(".")))
+
+	var buf bytes.Buffer
+	r.PrintRoutes(&buf)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	if len(lines) != 1+len(methodMap)+2 {
+		t.Fatalf("unexpected number of lines:\n%s", buf.String())
+	}
+	if fields := strings.Fields(lines[0]); fmt.Sprint(fields) != "[METHOD PATTERN HANDLER MIDDLEWARES]" {
+		t.Fatalf("unexpected header %q", lines[0])
+	}
+	expected := map[string]bool{
+		"POST /api/items github.com/SirMetathyst/go-penguin.printRoutesHandler 2": false,
+		"GET /files *http.fileHandler 1":                                         false,
+		"GET /users github.com/SirMetathyst/go-penguin.printRoutesHandler 1":     false,
+	}
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if _, ok := expected[line]; ok {
+			expected[line] = true
+		}
+	}
+	for line, found := range expected {
+		if !found {
+			t.Errorf("expecting line %q in:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestMuxRoutesHandler(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	r := New()
+	r.Get("/users", h)
+	r.Route("/api", func(r Router) {
+		r.Route("/items", func(r Router) {
+			r.Delete("/{id}", h)
+		})
+	})
+	r.Get("/_routes", r.RoutesHandler())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/_routes", nil))
+
+	var routes []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	expected := []RouteInfo{
+		{"GET", "/_routes", false},
+		{"DELETE", "/api/items/{id}", true},
+		{"GET", "/users", false},
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Fatalf("expecting routes %v, got %v", expected, routes)
+	}
+}
+
+func TestMuxRemoveRouteWhileServing(t *testing.T) {
+	r := New()
+	for i := 0; i < 50; i++ {
+		r.Get(fmt.Sprintf("/route/%d", i), func(w http.ResponseWriter, r *http.Request) {})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			testHandler(t, r, "GET", fmt.Sprintf("/route/%d", i), nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if err := r.RemoveRoute("GET", fmt.Sprintf("/route/%d", i)); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	if len(r.Routes()) != 0 {
+		t.Fatalf("expecting all routes to be removed, got %d", len(r.Routes()))
+	}
+}
+
+func TestMuxDefaultHandlersRequestID(t *testing.T) {
+	r := New()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), RequestIDKey, "req-404")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Get("/hi", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hi"))
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := testRequest(t, ts, "GET", "/nothing-here", nil)
+	if resp.StatusCode != 404 || resp.Header.Get("X-Request-Id") != "req-404" {
+		t.Fatalf("expecting 404 with request id, got %d %q", resp.StatusCode, resp.Header.Get("X-Request-Id"))
+	}
+	resp, _ = testRequest(t, ts, "POST", "/hi", nil)
+	if resp.StatusCode != 405 || resp.Header.Get("X-Request-Id") != "req-404" {
+		t.Fatalf("expecting 405 with request id, got %d %q", resp.StatusCode, resp.Header.Get("X-Request-Id"))
+	}
+}
+
+func TestMuxMethodNotAllowedAllowedMethods(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := New()
+	r.Get("/articles", h)
+	r.Post("/articles", h)
+	r.Route("/users", func(r Router) {
+		r.Delete("/{id}", h)
+		r.Put("/{id}", h)
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, "PATCH", "/articles", nil)
+	if resp.StatusCode != 405 || body != "" {
+		t.Fatalf("expecting 405 with an empty body, got %d %q", resp.StatusCode, body)
+	}
+	if allow := resp.Header.Get("Allow"); allow != "GET, POST" {
+		t.Fatalf("unexpected Allow header %q", allow)
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/users/1", nil)
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != 405 || resp.Header.Get("Allow") != "DELETE, PUT" {
+		t.Fatalf("expecting 405 with an Allow header, got %d %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	if string(b) != `{"allowed":["DELETE","PUT"],"error":"method not allowed"}`+"\n" {
+		t.Fatalf("unexpected body %q", b)
+	}
+}
+
+func TestMuxNamedRoutes(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := New()
+	r.Get("/users/{id}", h)
+	r.Name("user.show", "/users/{id}")
+	r.Group(func(r Router) {
+		r.Get("/articles/{year:[0-9]{4}}/{slug}", h)
+		r.Name("article.show", "/articles/{year:[0-9]{4}}/{slug}")
+	})
+	r.Route("/orgs/{org}", func(r Router) {
+		r.Route("/teams", func(r Router) {
+			r.Get("/{team}", h)
+			r.Name("team.show", "/{team}")
+		})
+	})
+	r.Get("/files/{name?}", h)
+	r.Name("files", "/files/{name?}")
+	r.Name("home", "/{lang?:[a-z]{2}}")
+
+	tests := []struct {
+		name     string
+		params   map[string]string
+		expected string
+	}{
+		{"user.show", map[string]string{"id": "42"}, "/users/42"},
+		{"user.show", map[string]string{"id": "a b"}, "/users/a%20b"},
+		{"article.show", map[string]string{"year": "2023", "slug": "hello"}, "/articles/2023/hello"},
+		{"team.show", map[string]string{"org": "acme", "team": "core"}, "/orgs/acme/teams/core"},
+		{"files", map[string]string{"name": "a.txt"}, "/files/a.txt"},
+		{"files", nil, "/files"},
+		{"home", map[string]string{"lang": "fr"}, "/fr"},
+		{"home", nil, "/"},
+	}
+	for _, tt := range tests {
+		u, err := r.URL(tt.name, tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != tt.expected {
+			t.Errorf("%s: URL %q, expecting %q", tt.name, u, tt.expected)
+		}
+	}
+
+	if _, err := r.URL("missing", nil); err == nil {
+		t.Fatal("expecting an error for an unknown name")
+	}
+	if _, err := r.URL("team.show", map[string]string{"team": "core"}); err == nil {
+		t.Fatal("expecting an error for a missing param")
+	}
+	if _, err := r.URL("article.show", map[string]string{"year": "23", "slug": "x"}); err == nil {
+		t.Fatal("expecting an error for a param not matching its regexp")
+	}
+}
+
+func TestMuxNamedRouteParamType(t *testing.T) {
+	if err := RegisterParamType("slug", `[a-z0-9-]+`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		paramTypesMu.Lock()
+		delete(paramTypes, "slug")
+		paramTypesMu.Unlock()
+	})
+
+	r := New()
+	r.Get("/posts/{id:slug}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Name("post", "/posts/{id:slug}")
+
+	if u, err := r.URL("post", map[string]string{"id": "hello-world"}); err != nil || u != "/posts/hello-world" {
+		t.Fatalf("expecting /posts/hello-world, got %q %v", u, err)
+	}
+	if _, err := r.URL("post", map[string]string{"id": "Hello World"}); err == nil {
+		t.Fatal("expecting an error for a param not matching its param type")
+	}
+}
+
+func TestMuxStaticAt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.StaticAt("/assets", dir)
+	r.StaticFSAt("/embedded/", fstest.MapFS{"logo.svg": {Data: []byte("<svg/>")}})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	if resp, body := testRequest(t, ts, "GET", "/assets/app.css", nil); resp.StatusCode != 200 || body != "body{}" {
+		t.Fatalf("expecting the asset, got %d %q", resp.StatusCode, body)
+	}
+	if resp, body := testRequest(t, ts, "GET", "/embedded/logo.svg", nil); resp.StatusCode != 200 || body != "<svg/>" {
+		t.Fatalf("expecting the embedded asset, got %d %q", resp.StatusCode, body)
+	}
+	if resp, _ := testRequest(t, ts, "GET", "/static/app.css", nil); resp.StatusCode != 404 {
+		t.Fatalf("expecting no /static route, got %d", resp.StatusCode)
+	}
+	for _, path := range []string{"/assets/../secret.txt", "/assets/%2e%2e/secret.txt", "/assets/..%2fsecret.txt"} {
+		if _, body := testRequest(t, ts, "GET", path, nil); body == "secret" {
+			t.Fatalf("%s: traversal escaped the static root", path)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting a panic for a prefix without a leading slash")
+		}
+	}()
+	r.StaticAt("assets", dir)
+}
+
+func TestMuxStaticFile(t *testing.T) {
+	dir := t.TempDir()
+	robots := filepath.Join(dir, "robots.txt")
+	if err := os.WriteFile(robots, []byte("User-agent: *"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte("custom 404"))
+	})
+	r.StaticFile("/robots.txt", robots)
+	r.StaticFile("/missing.txt", filepath.Join(dir, "missing.txt"))
+	r.StaticFileFS("/favicon.svg", fstest.MapFS{"icons/favicon.svg": {Data: []byte("<svg/>")}}, "icons/favicon.svg")
+	r.StaticFileFS("/gone.svg", fstest.MapFS{}, "gone.svg")
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	tests := []struct {
+		path, contentType, body string
+		status                  int
+	}{
+		{"/robots.txt", "text/plain; charset=utf-8", "User-agent: *", 200},
+		{"/favicon.svg", "image/svg+xml", "<svg/>", 200},
+		{"/missing.txt", "", "custom 404", 404},
+		{"/gone.svg", "", "custom 404", 404},
+	}
+	for _, tt := range tests {
+		resp, body := testRequest(t, ts, "GET", tt.path, nil)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status %d, expecting %d", tt.path, resp.StatusCode, tt.status)
+			continue
+		}
+		if tt.status != 200 {
+			if body != tt.body {
+				t.Errorf("%s: expecting the NotFound handler, got %q", tt.path, body)
+			}
+			continue
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != tt.contentType || body != tt.body {
+			t.Errorf("%s: got %q %q, expecting %q %q", tt.path, ct, body, tt.contentType, tt.body)
+		}
+	}
+}
+
+func TestMuxMethodNotAllowedAllowHeader(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := New()
+	r.Get("/things", h)
+	r.Post("/things", h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/things", nil))
+	if w.Code != 405 || w.Header().Get("Allow") != "GET, POST" {
+		t.Fatalf("expecting 405 with 'Allow: GET, POST', got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	// custom handlers can read the allowed methods from the routing context
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(405)
+		fmt.Fprint(w, RouteContext(r.Context()).AllowedMethods())
+	})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/things", nil))
+	if w.Body.String() != "[GET POST]" {
+		t.Fatalf("unexpected allowed methods %q", w.Body.String())
+	}
+}
+
+func TestMuxAutoOptions(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := New()
+	r.AutoOptions(true)
+	r.Get("/articles", h)
+	r.Post("/articles", h)
+	r.Get("/custom", h)
+	r.Options("/custom", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("custom options"))
+	})
+	r.Route("/users", func(r Router) {
+		r.Delete("/{id}", h)
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := testRequest(t, ts, "OPTIONS", "/articles", nil)
+	if resp.StatusCode != 204 || resp.Header.Get("Allow") != "GET, OPTIONS, POST" {
+		t.Fatalf("expecting 204 with the allowed methods, got %d %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+	if resp, body := testRequest(t, ts, "OPTIONS", "/custom", nil); resp.StatusCode != 200 || body != "custom options" {
+		t.Fatalf("expecting the explicit OPTIONS handler, got %d %q", resp.StatusCode, body)
+	}
+	resp, _ = testRequest(t, ts, "OPTIONS", "/users/1", nil)
+	if resp.StatusCode != 204 || resp.Header.Get("Allow") != "DELETE, OPTIONS" {
+		t.Fatalf("expecting sub-routers to answer OPTIONS, got %d %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+	if resp, _ := testRequest(t, ts, "OPTIONS", "/missing", nil); resp.StatusCode != 404 {
+		t.Fatalf("expecting 404 for a missing path, got %d", resp.StatusCode)
+	}
+
+	ts.Close()
+	r.AutoOptions(false)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/articles", nil))
+	if w.Code != 405 {
+		t.Fatalf("expecting 405 once disabled, got %d", w.Code)
+	}
+}
+
+func TestMuxAutoHead(t *testing.T) {
+	get := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Id", URLParam(r, "id"))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("body"))
+	}
+
+	r := New()
+	r.AutoHead(true)
+	r.Get("/items/{id}", get)
+	r.Get("/explicit", get)
+	r.Head("/explicit", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Explicit", "yes")
+	})
+	r.Post("/post-only", get)
+	r.Route("/sub", func(r Router) {
+		r.Get("/", get)
+	})
+	r.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		stream, err := NewEventStream(w)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		stream.Send("ping", "1")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		header string
+	}{
+		{"/items/7", http.StatusAccepted, "7"},
+		{"/sub", http.StatusAccepted, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("HEAD", tt.path, nil))
+		if w.Code != tt.status || w.Header().Get("X-Method") != "HEAD" || w.Header().Get("X-Id") != tt.header {
+			t.Errorf("HEAD %s: got %d %v", tt.path, w.Code, w.Header())
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("HEAD %s: expecting an empty body, got %q", tt.path, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("HEAD", "/explicit", nil))
+	if w.Header().Get("X-Explicit") != "yes" {
+		t.Fatal("expecting the explicit HEAD handler to win")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("HEAD", "/events", nil))
+	if w.Code != http.StatusOK || !w.Flushed || w.Body.Len() != 0 {
+		t.Fatalf("expecting a flushed event stream without a body, got %d flushed:%v %q", w.Code, w.Flushed, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("HEAD", "/post-only", nil))
+	if w.Code != 405 {
+		t.Fatalf("expecting 405 for a route without GET, got %d", w.Code)
+	}
+
+	r.AutoHead(false)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("HEAD", "/items/7", nil))
+	if w.Code != 405 {
+		t.Fatalf("expecting 405 once disabled, got %d", w.Code)
+	}
+}
+
+func TestMuxRedirectTrailingSlash(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}
+
+	r := New()
+	r.RedirectTrailingSlash(true)
+	r.Get("/users", ok)
+	r.Post("/users", ok)
+	r.Get("/docs/", ok)
+	r.Head("/head/", ok)
+	r.Get("/both", ok)
+	r.Get("/both/", ok)
+	r.Route("/admin", func(r Router) {
+		r.Get("/settings/", ok)
+	})
+	r.Get("//evil.example/", ok)
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		location string
+	}{
+		{"GET", "/users/", 301, "/users"},
+		{"GET", "/users/?page=2", 301, "/users?page=2"},
+		{"HEAD", "/head", 301, "/head/"},
+		{"HEAD", "/docs", 404, ""},
+		{"POST", "/users/", 308, "/users"},
+		{"GET", "/docs", 301, "/docs/"},
+		{"GET", "/admin/settings", 301, "/admin/settings/"},
+		{"GET", "/both", 200, ""},
+		{"GET", "/both/", 200, ""},
+		{"GET", "/missing", 404, ""},
+		{"GET", "/missing/", 404, ""},
+		{"PUT", "/users/", 404, ""},
+		{"GET", "//evil.example", 404, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: got %d %q, expecting %d %q", tt.method, tt.path, w.Code, w.Header().Get("Location"), tt.status, tt.location)
+		}
+	}
+
+	// Following a redirect must land on the handler, not loop.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, httptest.NewRequest("GET", w.Header().Get("Location"), nil))
+	if w2.Code != 200 || w2.Body.String() != "/docs/" {
+		t.Fatalf("expecting the redirect target to be served, got %d %q", w2.Code, w2.Body.String())
+	}
+
+	r.RedirectTrailingSlash(false)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/", nil))
+	if w.Code != 404 {
+		t.Fatalf("expecting 404 once disabled, got %d", w.Code)
+	}
+}
+
+func TestMuxPanicReturnsContextToPool(t *testing.T) {
+	rethrow := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rvr := recover(); rvr != nil {
+					w.WriteHeader(500)
+					panic(rvr)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	for _, mws := range [][]func(http.Handler) http.Handler{nil, {rethrow}} {
+		var rctx *Context
+		r := New()
+		pool := &recordingPool{contextPool: r.pool}
+		r.pool = pool
+		r.Use(mws...)
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			rctx = RouteContext(r.Context())
+			panic("boom")
+		})
+
+		func() {
+			defer func() {
+				if rvr := recover(); rvr != "boom" {
+					t.Fatalf("expecting the panic to propagate, got %v", rvr)
+				}
+			}()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}()
+
+		if len(pool.puts) != 1 || pool.puts[0] != rctx {
+			t.Fatalf("expecting the routing context to be put back once, got %v", pool.puts)
+		}
+	}
+}
+
+// recordingPool is a contextPool recording the contexts put back into it.
+type recordingPool struct {
+	contextPool
+	puts []any
+}
+
+func (p *recordingPool) Put(x any) {
+	p.puts = append(p.puts, x)
+	p.contextPool.Put(x)
+}
+
+func TestMuxErrorTemplates(t *testing.T) {
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(
+		`{{define "404"}}<h1>{{.title}} not found</h1>{{end}}` +
+			`{{define "405"}}<h1>{{.title}} method not allowed</h1>{{end}}`,
+	)))
+	r.NotFoundTemplate("404", M{"title": "Penguin"})
+	r.MethodNotAllowedTemplate("405", M{"title": "Penguin"})
+	r.Get("/hi", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		method, path, accept string
+		status               int
+		body, allow          string
+	}{
+		{"GET", "/missing", "", 404, "<h1>Penguin not found</h1>", ""},
+		{"POST", "/hi", "", 405, "<h1>Penguin method not allowed</h1>", "GET"},
+		{"POST", "/hi", "application/json", 405, `{"allowed":["GET"],"error":"method not allowed"}` + "\n", "GET"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status || w.Body.String() != tt.body || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: got %d %q (Allow: %q)", tt.method, tt.path, w.Code, w.Body.String(), w.Header().Get("Allow"))
+		}
+	}
+
+	// Without a template engine the default handlers respond.
+	r = New()
+	r.NotFoundTemplate("404", nil)
+	r.Get("/hi", func(w http.ResponseWriter, r *http.Request) {})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != 404 || w.Body.String() != "404 page not found\n" {
+		t.Fatalf("expecting the default 404, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+type articleResource struct{}
+
+func (articleResource) Router(r Router) {
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Resource", "articles")
+			next.ServeHTTP(w, r)
+		})
+	})
+}
+
+func (articleResource) Index(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("index"))
+}
+
+func (articleResource) Show(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("show " + URLParam(r, "id")))
+}
+
+func (articleResource) Update(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("update " + URLParam(r, "id")))
+}
+
+func TestMuxResource(t *testing.T) {
+	r := New()
+	r.Resource("/articles", articleResource{})
+
+	var routes []string
+	Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	sort.Strings(routes)
+	expected := []string{
+		"GET /articles/",
+		"GET /articles/{id}",
+		"PATCH /articles/{id}",
+		"PUT /articles/{id}",
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Fatalf("expecting routes %v, got %v", expected, routes)
+	}
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, "GET", "/articles/42", nil)
+	if body != "show 42" || resp.Header.Get("X-Resource") != "articles" {
+		t.Fatalf("unexpected response %q with headers %v", body, resp.Header)
+	}
+	if _, body := testRequest(t, ts, "PATCH", "/articles/42", nil); body != "update 42" {
+		t.Fatalf(body)
+	}
+	if resp, _ := testRequest(t, ts, "POST", "/articles/", nil); resp.StatusCode != 405 {
+		t.Fatalf("expecting 405 creating an article, got %d", resp.StatusCode)
+	}
+	if resp, _ := testRequest(t, ts, "GET", "/articles/42/edit", nil); resp.StatusCode != 404 {
+		t.Fatalf("expecting 404 editing an article, got %d", resp.StatusCode)
+	}
+}
+
+type misspelledResource struct{}
+
+func (misspelledResource) Shw(w http.ResponseWriter, r *http.Request) {}
+
+func (misspelledResource) Index(w http.ResponseWriter) {}
+
+func TestMuxResourceWithoutActions(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting Resource to panic without any action or Router method")
+		}
+	}()
+	New().Resource("/articles", misspelledResource{})
+}
+
+type adminController struct{}
+
+func (adminController) Middlewares() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "admin" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		},
+	}
+}
+
+func (c adminController) Router(r Router) {
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("dashboard")) })
+	r.Get("/users", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("users")) })
+}
+
+func TestMuxControllerMiddleware(t *testing.T) {
+	r := New()
+	r.Controller("/admin", adminController{})
+	r.Get("/public", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("public")) })
+
+	for _, path := range []string{"/admin/", "/admin/users"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: expecting 401, got %d", path, w.Code)
+		}
+
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "admin")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expecting 200, got %d", path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/public", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "public" {
+		t.Fatalf("expecting the sibling route to skip the controller middleware, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+type setupController struct {
+	inits int
+	err   error
+}
+
+func (c *setupController) Init() error {
+	c.inits++
+	return c.err
+}
+
+func (c *setupController) Router(r Router) {
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ready")) })
+}
+
+func TestMuxControllerInit(t *testing.T) {
+	r := New()
+	c := &setupController{}
+	if err := r.ControllerE("/ok", c); err != nil {
+		t.Fatal(err)
+	}
+	if c.inits != 1 {
+		t.Fatalf("expecting Init to run once, ran %d times", c.inits)
+	}
+
+	errMissing := errors.New("missing database")
+	failing := &setupController{err: errMissing}
+	if err := r.ControllerE("/failing", failing); !errors.Is(err, errMissing) {
+		t.Fatalf("expecting the Init error, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/ok/", nil))
+	if w.Body.String() != "ready" {
+		t.Fatalf("expecting the controller route, got %q", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/failing/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expecting the failed controller to be unregistered, got %d", w.Code)
+	}
+
+	for name, register := range map[string]func(){
+		"Controller": func() { r.Controller("/panics", &setupController{err: errMissing}) },
+		"Resource":   func() { r.Resource("/panics", &setupController{err: errMissing}) },
+	} {
+		func() {
+			defer func() {
+				if err, ok := recover().(error); !ok || !errors.Is(err, errMissing) {
+					t.Fatalf("expecting %s to panic with the Init error, got %v", name, err)
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestMuxHandle404Fallthrough(t *testing.T) {
+	legacy := New()
+	legacy.Get("/api/v0/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("legacy " + r.URL.Path + " " + URLParam(r, "id")))
+	})
+	legacy.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("legacy miss " + r.URL.Path))
+	})
+
+	r := New()
+	r.Route("/api", func(r Router) {
+		r.Get("/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("v1 " + URLParam(r, "id")))
+		})
+	})
+	r.Handle404Fallthrough(legacy)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/api/v1/users/1", 200, "v1 1"},
+		{"/api/v0/users/2", 200, "legacy /api/v0/users/2 2"},
+		{"/other", 404, "legacy miss /other"},
+	}
+	for _, tt := range tests {
+		resp, body := testRequest(t, ts, "GET", tt.path, nil)
+		if resp.StatusCode != tt.status || body != tt.body {
+			t.Errorf("%s: got %d %q, expecting %d %q", tt.path, resp.StatusCode, body, tt.status, tt.body)
+		}
+	}
+}
+
+func TestMuxWithTimeout(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			w.Write([]byte(r.Context().Err().Error()))
+		case <-time.After(time.Second):
+			w.Write([]byte("finished"))
+		}
+	}
+
+	r := New()
+	r.WithTimeout(10 * time.Millisecond).Group(func(r Router) {
+		r.Get("/report", slow)
+	})
+	r.Get("/fast", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			w.Write([]byte("deadline"))
+			return
+		}
+		w.Write([]byte("no deadline"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+	if w.Body.String() != context.DeadlineExceeded.Error() {
+		t.Fatalf("expecting the slow handler's context to be cancelled, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	if w.Body.String() != "no deadline" {
+		t.Fatalf("expecting routes outside the group to have no deadline, got %q", w.Body.String())
+	}
+}
+
+func TestMuxSubRouters(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	admin := New()
+	admin.Get("/", h)
+
+	r := New()
+	r.Get("/", h)
+	r.Mount("/admin", admin)
+	r.Route("/api", func(r Router) {
+		r.Route("/v1", func(r Router) {
+			r.Get("/users", h)
+		})
+	})
+
+	subRouters := r.SubRouters()
+	if len(subRouters) != 2 {
+		t.Fatalf("expecting 2 sub-routers, got %d", len(subRouters))
+	}
+	if subRouters["/admin"] != admin {
+		t.Fatal("expecting the admin router to be mounted on /admin")
+	}
+	api, ok := subRouters["/api"].(*Engine)
+	if !ok {
+		t.Fatal("expecting a sub-router mounted on /api")
+	}
+	if _, ok := api.SubRouters()["/v1"]; !ok {
+		t.Fatal("expecting a nested sub-router mounted on /api/v1")
+	}
+}
+
+func TestMuxMountSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":        {Data: []byte("<html>spa</html>")},
+		"static/app.js":     {Data: []byte("console.log('app')")},
+		"static/css/ui.css": {Data: []byte("body{}")},
+	}
+
+	r := New()
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("home"))
+	})
+	r.MountSPA("/app", fsys)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	tests := []struct {
+		path, body, contentType string
+	}{
+		{"/app", "<html>spa</html>", "text/html; charset=utf-8"},
+		{"/app/", "<html>spa</html>", "text/html; charset=utf-8"},
+		{"/app/some/deep/route", "<html>spa</html>", "text/html; charset=utf-8"},
+		{"/app/static", "<html>spa</html>", "text/html; charset=utf-8"},
+		{"/app/static/app.js", "console.log('app')", "text/javascript; charset=utf-8"},
+		{"/app/static/css/ui.css", "body{}", "text/css; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		resp, body := testRequest(t, ts, "GET", tt.path, nil)
+		if resp.StatusCode != 200 || body != tt.body {
+			t.Fatalf("GET %s: expecting 200 %q, got %d %q", tt.path, tt.body, resp.StatusCode, body)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != tt.contentType {
+			t.Fatalf("GET %s: expecting Content-Type %q, got %q", tt.path, tt.contentType, ct)
+		}
+	}
+
+	if _, body := testRequest(t, ts, "GET", "/", nil); body != "home" {
+		t.Fatalf(body)
+	}
+}
+
+func TestMuxStaticSPA(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "assets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"app.html": "<html>spa</html>", "assets/main.js": "main()"}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := New()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte("custom 404"))
+	})
+	r.StaticSPA("/ui", dir, "app.html")
+	r.StaticSPAFS("/embedded", os.DirFS(dir), "app.html")
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, prefix := range []string{"/ui", "/embedded"} {
+		tests := []struct {
+			path, body string
+			status     int
+		}{
+			{"/assets/main.js", "main()", 200},
+			{"/users/42/settings", "<html>spa</html>", 200},
+			{"/", "<html>spa</html>", 200},
+			{"/assets/missing.js", "custom 404", 404},
+			{"/assets/missing.CSS", "custom 404", 404},
+		}
+		for _, tt := range tests {
+			resp, body := testRequest(t, ts, "GET", prefix+tt.path, nil)
+			if resp.StatusCode != tt.status || body != tt.body {
+				t.Errorf("GET %s%s: got %d %q, expecting %d %q", prefix, tt.path, resp.StatusCode, body, tt.status, tt.body)
+			}
+		}
+	}
+}
+
 func TestServerBaseContext(t *testing.T) {
 	r := New()
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
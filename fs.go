@@ -0,0 +1,137 @@
+package penguin
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// MergeFS returns a file system that overlays the given file systems, for use
+// with HTMLFs or StaticFS when assets are spread over several embedded file
+// systems, such as a shared component library plus application overrides.
+//
+// Files are looked up in the order the file systems are given and the first
+// match wins, so earlier file systems override later ones. Directories are
+// merged: listing a directory returns the union of its entries across every
+// file system, with name collisions resolved by the same precedence and the
+// entries sorted by name.
+func MergeFS(fsys ...fs.FS) fs.FS {
+	return mergedFS(fsys)
+}
+
+type mergedFS []fs.FS
+
+var _ fs.ReadDirFS = mergedFS{}
+
+// Open opens the named file from the first file system containing it.
+// A directory is opened as the merge of that directory in every file system.
+func (m mergedFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	// An error of a file system, such as a file named like a parent directory
+	// of `name`, is only returned if no later file system holds `name`.
+	var firstErr error
+	for i, fsys := range m {
+		f, err := fsys.Open(name)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if !info.IsDir() {
+			return f, nil
+		}
+		return &mergedDir{File: f, fsys: m[i:], name: name}, nil
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir reads the named directory from every file system containing it
+// and returns the merged entries sorted by filename. Once the directory is
+// found, later file systems holding a file, rather than a directory, under the
+// name are skipped, since the directory takes precedence.
+func (m mergedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	var found bool
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	for _, fsys := range m {
+		if found {
+			if info, err := fs.Stat(fsys, name); err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		des, err := fs.ReadDir(fsys, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, de := range des {
+			if seen[de.Name()] {
+				continue
+			}
+			seen[de.Name()] = true
+			entries = append(entries, de)
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// mergedDir is a directory opened from a mergedFS. Stat is answered by the
+// highest precedence directory while ReadDir lists the merged entries.
+type mergedDir struct {
+	fs.File
+	fsys    mergedFS
+	name    string
+	entries []fs.DirEntry
+	offset  int
+	read    bool
+}
+
+func (d *mergedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.read = entries, true
+	}
+
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
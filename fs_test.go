@@ -0,0 +1,86 @@
+package penguin
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMergeFS(t *testing.T) {
+	shared := fstest.MapFS{
+		"html/layout.tmpl": {Data: []byte(`{{define "layout"}}shared layout{{end}}`)},
+		"html/button.tmpl": {Data: []byte(`{{define "button"}}shared button{{end}}`)},
+		"static/app.css":   {Data: []byte("shared css")},
+	}
+	app := fstest.MapFS{
+		"html/button.tmpl": {Data: []byte(`{{define "button"}}app button{{end}}`)},
+		"static/logo.svg":  {Data: []byte("<svg/>")},
+	}
+
+	fsys := MergeFS(app, shared)
+
+	if err := fstest.TestFS(fsys, "html/layout.tmpl", "html/button.tmpl", "static/app.css", "static/logo.svg"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := fs.ReadFile(fsys, "html/button.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{{define "button"}}app button{{end}}` {
+		t.Fatalf("expecting the first file system to win, got %q", b)
+	}
+
+	entries, err := fs.ReadDir(fsys, "static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name() != "app.css" || entries[1].Name() != "logo.svg" {
+		t.Fatalf("unexpected merged directory entries: %v", entries)
+	}
+
+	if _, err := fsys.Open("missing.txt"); err == nil {
+		t.Fatal("expecting an error opening a missing file")
+	}
+
+	// A later file named like an earlier directory doesn't break the listing.
+	colliding := MergeFS(fstest.MapFS{"tpl/x.html": {Data: []byte("x")}}, fstest.MapFS{"tpl": {Data: []byte("file")}})
+	entries, err = fs.ReadDir(colliding, "tpl")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "x.html" {
+		t.Fatalf("expecting the directory of the first file system, got %v %v", entries, err)
+	}
+	if matches, err := fs.Glob(colliding, "tpl/*.html"); err != nil || len(matches) != 1 {
+		t.Fatalf("expecting tpl/x.html to match, got %v %v", matches, err)
+	}
+
+	// An earlier file named like a later directory doesn't hide its files.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tpl"), []byte("file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	shadowed := MergeFS(os.DirFS(dir), fstest.MapFS{"tpl/x.html": {Data: []byte("x")}})
+	if b, err := fs.ReadFile(shadowed, "tpl/x.html"); err != nil || string(b) != "x" {
+		t.Fatalf("expecting tpl/x.html from the later file system, got %q %v", b, err)
+	}
+
+	r := New()
+	r.HTMLFs(fsys, "html/*.tmpl")
+	r.StaticFS(fsys)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		HTML(w, r, 200, "button", nil)
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	if _, body := testRequest(t, ts, "GET", "/", nil); body != "app button" {
+		t.Fatalf(body)
+	}
+	if _, body := testRequest(t, ts, "GET", "/static/static/app.css", nil); body != "shared css" {
+		t.Fatalf(body)
+	}
+}
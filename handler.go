@@ -0,0 +1,39 @@
+package penguin
+
+import "net/http"
+
+// DataHandlerFunc is a handler that returns the data to respond with, or an
+// error, rather than writing the response itself. This lets controller methods
+// be written as thin data-returning methods, for example:
+//
+//	func (c *ArticleController) Router(r penguin.Router) {
+//		r.Get("/{id}", penguin.DataHandler(c.Show))
+//	}
+//
+//	func (c *ArticleController) Show(r *http.Request) (any, error) {
+//		return c.store.Article(penguin.URLParam(r, "id"))
+//	}
+type DataHandlerFunc func(r *http.Request) (any, error)
+
+// ServeHTTP calls fn and renders the returned data with a 200 using Negotiate,
+// or a 204 when the data is nil. Errors are responded to with Error.
+func (fn DataHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v, err := fn(r)
+	if err != nil {
+		Error(w, r, err)
+		return
+	}
+	if v == nil {
+		NoContent(w, r)
+		return
+	}
+	if err := Negotiate(w, r, http.StatusOK, v); err != nil {
+		Error(w, r, err)
+	}
+}
+
+// DataHandler adapts a data-returning function to a http.HandlerFunc so it can
+// be registered with the routing methods of a Router. See DataHandlerFunc.
+func DataHandler(fn func(r *http.Request) (any, error)) http.HandlerFunc {
+	return DataHandlerFunc(fn).ServeHTTP
+}
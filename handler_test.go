@@ -0,0 +1,109 @@
+package penguin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type article struct {
+	ID    string `json:"id" xml:"id"`
+	Title string `json:"title" xml:"title"`
+}
+
+type articleController struct{}
+
+func (c *articleController) Router(r Router) {
+	r.Get("/{id}", DataHandler(c.Show))
+	r.Delete("/{id}", DataHandler(c.Delete))
+	r.Get("/legacy", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("legacy"))
+	})
+}
+
+func (c *articleController) Show(r *http.Request) (any, error) {
+	switch id := URLParam(r, "id"); id {
+	case "missing":
+		return nil, NewHTTPError(http.StatusNotFound, "article not found")
+	case "broken":
+		return nil, errors.New("database is down")
+	default:
+		return article{ID: id, Title: "Hello"}, nil
+	}
+}
+
+func (c *articleController) Delete(r *http.Request) (any, error) {
+	return nil, nil
+}
+
+func TestDataHandler(t *testing.T) {
+	r := New()
+	r.Controller("/articles", &articleController{})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, "GET", "/articles/1", nil)
+	if resp.Header.Get("Content-Type") != "application/json; charset=utf-8" || body != "{\"id\":\"1\",\"title\":\"Hello\"}\n" {
+		t.Fatalf("unexpected JSON response %q %q", resp.Header.Get("Content-Type"), body)
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/articles/1", nil)
+	req.Header.Set("Accept", "application/xml")
+	xresp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xresp.Body.Close()
+	if xresp.Header.Get("Content-Type") != "application/xml; charset=utf-8" {
+		t.Fatalf("expecting an XML response, got %q", xresp.Header.Get("Content-Type"))
+	}
+
+	if resp, body := testRequest(t, ts, "GET", "/articles/missing", nil); resp.StatusCode != 404 || body != "article not found\n" {
+		t.Fatalf("unexpected error response %d %q", resp.StatusCode, body)
+	}
+	if resp, body := testRequest(t, ts, "GET", "/articles/broken", nil); resp.StatusCode != 500 || body != "Internal Server Error\n" {
+		t.Fatalf("unexpected error response %d %q", resp.StatusCode, body)
+	}
+	if resp, _ := testRequest(t, ts, "DELETE", "/articles/1", nil); resp.StatusCode != 204 {
+		t.Fatalf("expecting 204 for nil data, got %d", resp.StatusCode)
+	}
+	if _, body := testRequest(t, ts, "GET", "/articles/legacy", nil); body != "legacy" {
+		t.Fatalf(body)
+	}
+}
+
+func TestError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/json")
+	Error(w, r, fmt.Errorf("loading: %w", NewHTTPError(http.StatusConflict, "")))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expecting 409, got %d", w.Code)
+	}
+	if body := w.Body.String(); body != "{\"error\":\"Conflict\"}\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	Error(w, httptest.NewRequest("GET", "/", nil), fmt.Errorf("user 42: %w", NewHTTPError(http.StatusNotFound, "user not found")))
+	if w.Code != http.StatusNotFound || w.Body.String() != "user not found\n" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	Error(w, httptest.NewRequest("GET", "/", nil), fmt.Errorf("wrapped: %w", statusError{"secret dsn"}))
+	if w.Code != http.StatusBadGateway || w.Body.String() != "Bad Gateway\n" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+}
+
+// statusError is an error with a status code whose message isn't meant for
+// clients.
+type statusError struct{ msg string }
+
+func (e statusError) Error() string   { return e.msg }
+func (e statusError) StatusCode() int { return http.StatusBadGateway }
@@ -0,0 +1,133 @@
+package penguin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ListOptions configures how ListParams parses the query string of a list
+// endpoint.
+type ListOptions struct {
+	// SortFields are the fields that may be sorted on. Any other field in the
+	// sort parameter is rejected, so it's safe to use the parsed fields in a
+	// query.
+	SortFields []string
+
+	// FilterFields are the fields that may be filtered on with the
+	// filter[field] parameters. Any other filter is rejected.
+	FilterFields []string
+
+	// DefaultSort is used when the request has no sort parameter.
+	DefaultSort []SortField
+
+	// DefaultLimit is the page size used when the request has no limit
+	// parameter, defaults to 20.
+	DefaultLimit int
+
+	// MaxLimit is the largest page size a request may ask for, defaults to
+	// 100.
+	MaxLimit int
+}
+
+// SortField is a field to sort a list on.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListQuery is the sorting, filtering and pagination of a list request parsed
+// by ListParams.
+type ListQuery struct {
+	Sort    []SortField
+	Filters map[string]string
+	Page    int
+	Limit   int
+}
+
+// Offset returns the number of items to skip to reach the current page.
+func (q ListQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// ListParams parses the sorting, filtering and pagination parameters of a list
+// request, as in:
+//
+//	/articles?sort=title,-created_at&filter[status]=active&page=2&limit=50
+//
+// A `-` prefixed sort field sorts in descending order. Sort and filter fields
+// are validated against the allowlists of `opts`, and the page and limit must
+// be positive integers, the limit being capped at opts.MaxLimit. Invalid
+// parameters are reported with a *HTTPError carrying a 400 Bad Request status,
+// ready to be passed to Error.
+func ListParams(r *http.Request, opts ListOptions) (ListQuery, error) {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 20
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 100
+	}
+
+	query := r.URL.Query()
+	q := ListQuery{Page: 1, Limit: opts.DefaultLimit, Filters: map[string]string{}}
+
+	if sort := query.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			sf := SortField{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(sf.Field, "-") {
+				sf.Field, sf.Desc = sf.Field[1:], true
+			}
+			if !containsString(opts.SortFields, sf.Field) {
+				return ListQuery{}, listParamError("invalid sort field '%s'", sf.Field)
+			}
+			q.Sort = append(q.Sort, sf)
+		}
+	} else {
+		q.Sort = append(q.Sort, opts.DefaultSort...)
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		field := key[len("filter[") : len(key)-1]
+		if !containsString(opts.FilterFields, field) {
+			return ListQuery{}, listParamError("invalid filter field '%s'", field)
+		}
+		q.Filters[field] = values[0]
+	}
+
+	if page := query.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return ListQuery{}, listParamError("invalid page '%s'", page)
+		}
+		q.Page = n
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return ListQuery{}, listParamError("invalid limit '%s'", limit)
+		}
+		if n > opts.MaxLimit {
+			n = opts.MaxLimit
+		}
+		q.Limit = n
+	}
+
+	return q, nil
+}
+
+func listParamError(format string, args ...any) error {
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf(format, args...))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package penguin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListParams(t *testing.T) {
+	opts := ListOptions{
+		SortFields:   []string{"title", "created_at"},
+		FilterFields: []string{"status"},
+		DefaultSort:  []SortField{{Field: "created_at", Desc: true}},
+	}
+
+	req := httptest.NewRequest("GET", "/?sort=title,-created_at&filter[status]=active&page=2&limit=50", nil)
+	q, err := ListParams(req, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ListQuery{
+		Sort:    []SortField{{Field: "title"}, {Field: "created_at", Desc: true}},
+		Filters: map[string]string{"status": "active"},
+		Page:    2,
+		Limit:   50,
+	}
+	if !reflect.DeepEqual(q, expected) {
+		t.Fatalf("unexpected list query %+v", q)
+	}
+	if q.Offset() != 50 {
+		t.Fatalf("expecting offset 50, got %d", q.Offset())
+	}
+
+	q, err = ListParams(httptest.NewRequest("GET", "/?limit=1000", nil), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Page != 1 || q.Limit != 100 || !reflect.DeepEqual(q.Sort, opts.DefaultSort) {
+		t.Fatalf("unexpected defaults %+v", q)
+	}
+}
+
+func TestListParamsInvalid(t *testing.T) {
+	opts := ListOptions{SortFields: []string{"title"}, FilterFields: []string{"status"}}
+
+	for _, query := range []string{
+		"sort=password",
+		"sort=title%3BDROP%20TABLE%20users",
+		"filter[owner]=1",
+		"page=0",
+		"limit=abc",
+	} {
+		_, err := ListParams(httptest.NewRequest("GET", "/?"+query, nil), opts)
+		var herr *HTTPError
+		if !errors.As(err, &herr) || herr.StatusCode() != http.StatusBadRequest {
+			t.Errorf("%s: expecting a 400 HTTPError, got %v", query, err)
+		}
+	}
+}
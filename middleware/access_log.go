@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// AccessLogOptions configures the AccessLog middleware.
+type AccessLogOptions struct {
+	// Writer receives one JSON object per logged request, followed by a
+	// newline. Defaults to os.Stdout.
+	Writer io.Writer
+
+	// SampleRate is the fraction of requests, between 0 and 1, that are
+	// logged. A rate of 0 or less logs no request besides those matching
+	// AlwaysLogStatusGTE, and 1 or more logs every request. When neither
+	// SampleRate nor AlwaysLogStatusGTE is set it defaults to 1.
+	SampleRate float64
+
+	// AlwaysLogStatusGTE logs every response with a status greater than or
+	// equal to it regardless of SampleRate, 400 logs all client and server
+	// errors for example. Zero leaves every request to sampling.
+	AlwaysLogStatusGTE int
+
+	// Rand returns the pseudo-random numbers in [0, 1) used for sampling. It
+	// defaults to math/rand.Float64 and is called with a lock held, so a
+	// seeded *rand.Rand's Float64 method is safe to use.
+	Rand func() float64
+}
+
+// accessLogRecord is the JSON object written by AccessLog.
+type accessLogRecord struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Bytes      int       `json:"bytes"`
+	DurationMs float64   `json:"duration_ms"`
+	RemoteIP   string    `json:"remote_ip"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// AccessLog is a middleware that writes a JSON access log line for a sample of
+// the requests, and for every request whose response status is at least
+// AlwaysLogStatusGTE, to keep the logs of busy services small while still
+// catching every error. For example, to log 1% of requests and all the 5xx:
+//
+//	r.Use(middleware.AccessLog(middleware.AccessLogOptions{
+//		SampleRate:         0.01,
+//		AlwaysLogStatusGTE: 500,
+//	}))
+func AccessLog(opts AccessLogOptions) func(next http.Handler) http.Handler {
+	if opts.Writer == nil {
+		opts.Writer = os.Stdout
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.Float64
+	}
+	if opts.SampleRate == 0 && opts.AlwaysLogStatusGTE == 0 {
+		opts.SampleRate = 1
+	}
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(opts.Writer)
+
+	sampled := func(status int) bool {
+		if opts.AlwaysLogStatusGTE > 0 && status >= opts.AlwaysLogStatusGTE {
+			return true
+		}
+		if opts.SampleRate <= 0 {
+			return false
+		}
+		if opts.SampleRate >= 1 {
+			return true
+		}
+		return opts.Rand() < opts.SampleRate
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if !sampled(status) {
+					return
+				}
+				_ = enc.Encode(accessLogRecord{
+					Time:       start.UTC(),
+					Method:     r.Method,
+					Path:       r.URL.Path,
+					Status:     status,
+					Bytes:      ww.BytesWritten(),
+					DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+					RemoteIP:   remoteHost(r),
+					RequestID:  GetReqID(r.Context()),
+				})
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
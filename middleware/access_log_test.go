@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	handler := AccessLog(AccessLogOptions{
+		Writer:             &buf,
+		SampleRate:         0.25,
+		AlwaysLogStatusGTE: 500,
+		Rand:               rand.New(rand.NewSource(1)).Float64,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("body"))
+	}))
+
+	const n = 400
+	for i := 0; i < n; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+	}
+
+	var ok, failed int
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec accessLogRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, "GET", rec.Method)
+		assertEqual(t, 4, rec.Bytes)
+		switch rec.Status {
+		case http.StatusOK:
+			ok++
+		case http.StatusInternalServerError:
+			failed++
+		}
+	}
+
+	assertEqual(t, n, failed)
+	if ok < n/8 || ok > n*3/8 {
+		t.Fatalf("expecting about a quarter of the %d successful requests to be logged, got %d", n, ok)
+	}
+}
+
+func TestAccessLogDeterministic(t *testing.T) {
+	run := func() string {
+		var buf bytes.Buffer
+		handler := AccessLog(AccessLogOptions{
+			Writer:     &buf,
+			SampleRate: 0.5,
+			Rand:       rand.New(rand.NewSource(7)).Float64,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		var logged []byte
+		for i := 0; i < 20; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			if buf.Len() > 0 {
+				logged = append(logged, '1')
+				buf.Reset()
+			} else {
+				logged = append(logged, '0')
+			}
+		}
+		return string(logged)
+	}
+
+	first := run()
+	assertEqual(t, first, run())
+	if first == "00000000000000000000" || first == "11111111111111111111" {
+		t.Fatalf("expecting a mix of sampled and skipped requests, got %s", first)
+	}
+}
+
+func TestAccessLogSampleRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     AccessLogOptions
+		expected []int
+	}{
+		{"defaults", AccessLogOptions{}, []int{200, 404, 500}},
+		{"errors only", AccessLogOptions{SampleRate: 0, AlwaysLogStatusGTE: 400}, []int{404, 500}},
+		{"server errors only", AccessLogOptions{SampleRate: -1, AlwaysLogStatusGTE: 500}, []int{500}},
+		{"everything", AccessLogOptions{SampleRate: 1, AlwaysLogStatusGTE: 500}, []int{200, 404, 500}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		tt.opts.Writer = &buf
+		handler := AccessLog(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/missing":
+				w.WriteHeader(http.StatusNotFound)
+			case "/fail":
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		for _, path := range []string{"/ok", "/missing", "/fail"} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}
+
+		logged := []int{}
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			var rec accessLogRecord
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			logged = append(logged, rec.Status)
+		}
+		if !reflect.DeepEqual(logged, tt.expected) {
+			t.Errorf("%s: expecting the statuses %v to be logged, got %v", tt.name, tt.expected, logged)
+		}
+	}
+}
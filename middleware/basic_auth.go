@@ -1,12 +1,21 @@
 package middleware
 
 import (
+	"context"
 	"crypto/subtle"
 	"fmt"
 	"net/http"
 )
 
+var (
+	// BasicAuthUserCtxKey is the context.Context key to store the username
+	// authenticated by BasicAuth.
+	BasicAuthUserCtxKey = &contextKey{"BasicAuthUser"}
+)
+
 // BasicAuth implements a simple middleware handler for adding basic http auth to a route.
+// Passwords are compared in constant time, and the authenticated username is stored in the
+// request context, see BasicAuthUser.
 func BasicAuth(realm string, creds map[string]string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -17,16 +26,24 @@ func BasicAuth(realm string, creds map[string]string) func(next http.Handler) ht
 			}
 
 			credPass, credUserOk := creds[user]
-			if !credUserOk || subtle.ConstantTimeCompare([]byte(pass), []byte(credPass)) != 1 {
+			if subtle.ConstantTimeCompare([]byte(pass), []byte(credPass)) != 1 || !credUserOk {
 				basicAuthFailed(w, realm)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			ctx := context.WithValue(r.Context(), BasicAuthUserCtxKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// BasicAuthUser returns the username authenticated by BasicAuth, or the empty
+// string if the request wasn't authenticated by it.
+func BasicAuthUser(ctx context.Context) string {
+	user, _ := ctx.Value(BasicAuthUserCtxKey).(string)
+	return user
+}
+
 func basicAuthFailed(w http.ResponseWriter, realm string) {
 	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
 	w.WriteHeader(http.StatusUnauthorized)
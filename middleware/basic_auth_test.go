@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestBasicAuth(t *testing.T) {
+	r := penguin.New()
+	r.Use(BasicAuth("internal", map[string]string{"admin": "s3cret"}))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello " + BasicAuthUser(r.Context())))
+	})
+
+	tests := []struct {
+		name       string
+		user, pass string
+		setAuth    bool
+		status     int
+		body       string
+	}{
+		{"valid credentials", "admin", "s3cret", true, 200, "hello admin"},
+		{"invalid password", "admin", "wrong", true, 401, ""},
+		{"unknown user", "guest", "s3cret", true, 401, ""},
+		{"empty password for unknown user", "guest", "", true, 401, ""},
+		{"missing header", "", "", false, 401, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.setAuth {
+			req.SetBasicAuth(tt.user, tt.pass)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, expecting %d %q", tt.name, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+		if tt.status == 401 {
+			assertEqual(t, `Basic realm="internal"`, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
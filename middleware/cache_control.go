@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachePolicy describes the Cache-Control header set by CacheControlPolicy.
+type CachePolicy struct {
+	// MaxAge is how long the response may be served from a cache.
+	MaxAge time.Duration
+
+	// Private restricts caching to the client, shared caches such as proxies
+	// and CDNs may not store the response. Responses are public otherwise.
+	Private bool
+
+	// StaleWhileRevalidate is how long a stale response may still be served
+	// while the cache revalidates it in the background.
+	StaleWhileRevalidate time.Duration
+
+	// NoStore forbids any cache from storing the response, all other fields
+	// are ignored.
+	NoStore bool
+}
+
+// String returns the policy formatted as a Cache-Control header value.
+func (p CachePolicy) String() string {
+	if p.NoStore {
+		return "no-store"
+	}
+
+	directives := []string{"public"}
+	if p.Private {
+		directives[0] = "private"
+	}
+	directives = append(directives, "max-age="+strconv.Itoa(int(p.MaxAge/time.Second)))
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+strconv.Itoa(int(p.StaleWhileRevalidate/time.Second)))
+	}
+	return strings.Join(directives, ", ")
+}
+
+// CacheControl is a middleware that sets a `Cache-Control: public, max-age=<d>`
+// header on responses, for example to cache a group of routes for an hour:
+//
+//	r.Group(func(r penguin.Router) {
+//		r.Use(middleware.CacheControl(time.Hour))
+//		r.Get("/articles", listArticles)
+//	})
+//
+// Handlers can still override the header by setting their own.
+func CacheControl(d time.Duration) func(http.Handler) http.Handler {
+	return CacheControlPolicy(CachePolicy{MaxAge: d})
+}
+
+// CacheControlPolicy is a middleware that sets the Cache-Control header
+// described by `p` on responses. See CachePolicy.
+func CacheControlPolicy(p CachePolicy) func(http.Handler) http.Handler {
+	value := p.String()
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", value)
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// NoStore is a middleware that sets a `Cache-Control: no-store` header on
+// responses, so routes serving sensitive data are never kept by a cache.
+func NoStore(next http.Handler) http.Handler {
+	return CacheControlPolicy(CachePolicy{NoStore: true})(next)
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		mw       func(http.Handler) http.Handler
+		expected string
+	}{
+		{"public", CacheControl(time.Hour), "public, max-age=3600"},
+		{"private", CacheControlPolicy(CachePolicy{MaxAge: time.Minute, Private: true}), "private, max-age=60"},
+		{"stale", CacheControlPolicy(CachePolicy{MaxAge: time.Minute, StaleWhileRevalidate: 30 * time.Second}), "public, max-age=60, stale-while-revalidate=30"},
+		{"no store", NoStore, "no-store"},
+	}
+	for _, tt := range tests {
+		r := penguin.New()
+		r.Use(tt.mw)
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if cc := w.Header().Get("Cache-Control"); cc != tt.expected {
+			t.Errorf("%s: Cache-Control = %q, expecting %q", tt.name, cc, tt.expected)
+		}
+	}
+}
+
+func TestCacheControlOverride(t *testing.T) {
+	r := penguin.New()
+	r.Use(CacheControl(time.Hour))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("expecting the handler to override the header, got %q", cc)
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+// CleanupMultipart is a middleware that removes the temporary files of the
+// multipart forms parsed with penguin.ParseMultipart once the handler returns.
+// The http.Server only cleans up the form of the request it passed to the
+// handler, so forms parsed on a copy of the request, such as one made with
+// r.WithContext by a middleware, are otherwise left behind on disk.
+func CleanupMultipart(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx, cleanup := penguin.WithMultipartCleanup(r.Context())
+		defer cleanup()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
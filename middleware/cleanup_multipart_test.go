@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestCleanupMultipart(t *testing.T) {
+	var tmpName string
+
+	r := penguin.New()
+	r.Use(CleanupMultipart)
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), penguin.RequestIDKey, "copy"))
+		if err := penguin.ParseMultipartMemory(r, 16); err != nil {
+			t.Fatal(err)
+		}
+		f, _ := r.MultipartForm.File["upload"][0].Open()
+		defer f.Close()
+		if osf, ok := f.(*os.File); ok {
+			tmpName = osf.Name()
+		}
+	})
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("upload", "big.bin")
+	fw.Write([]byte(strings.Repeat("x", 1024)))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if tmpName == "" {
+		t.Fatal("expecting the upload to be stored in a temporary file")
+	}
+	if _, err := os.Stat(tmpName); !os.IsNotExist(err) {
+		t.Fatalf("expecting the temporary file to be removed, got %v", err)
+	}
+}
@@ -15,7 +15,7 @@ import (
 )
 
 var defaultCompressibleContentTypes = []string{
-	"text/template",
+	"text/html",
 	"text/css",
 	"text/plain",
 	"text/javascript",
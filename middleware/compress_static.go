@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fingerprintedAsset matches asset names carrying a content hash, such as
+// app.3f2a1b9c.js or logo-3f2a1b9c0d.svg, capturing the hash.
+var fingerprintedAsset = regexp.MustCompile(`[.-]([0-9a-fA-F]{8,})\.[^./]+$`)
+
+// isFingerprinted reports whether the asset `path` carries a content hash. The
+// hash must hold a hex letter, so date-stamped names such as
+// report-20231015.pdf, which change content under the same name, aren't
+// mistaken for one.
+func isFingerprinted(path string) bool {
+	m := fingerprintedAsset.FindStringSubmatch(path)
+	return m != nil && strings.ContainsAny(m[1], "abcdefABCDEF")
+}
+
+// CompressStatic is a middleware for static file handlers, such as those
+// registered by Engine.Static, that compresses each asset once per encoding
+// and serves it from an in-memory cache thereafter, rather than compressing
+// it on every request.
+//
+// A cached asset is recompressed when the Last-Modified header of the file
+// changes. Assets served from an embedded file system have no modification
+// time and are never recompressed. Fingerprinted assets, whose name carries a
+// content hash like app.3f2a1b9c.js, are served with a long-lived immutable
+// Cache-Control header.
+//
+// The cache is unbounded, so only wrap handlers serving a finite set of files.
+func CompressStatic(level int, types ...string) func(next http.Handler) http.Handler {
+	return NewCompressor(level, types...).StaticHandler
+}
+
+type compressedAsset struct {
+	lastModified string
+	body         []byte
+}
+
+// StaticHandler returns a new middleware that will compress static assets
+// based on the current Compressor, caching the compressed bytes keyed by the
+// request path and encoding. See CompressStatic.
+func (c *Compressor) StaticHandler(next http.Handler) http.Handler {
+	var mu sync.RWMutex
+	cache := make(map[string]compressedAsset)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isFingerprinted(r.URL.Path) {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+
+		encoding := c.acceptedEncoding(r.Header)
+		if r.Method != http.MethodGet || r.Header.Get("Range") != "" || encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedResponse{header: make(http.Header), code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		compressable := &compressResponseWriter{
+			ResponseWriter:   rec,
+			contentTypes:     c.allowedTypes,
+			contentWildcards: c.allowedWildcards,
+		}
+		if rec.code != http.StatusOK || rec.header.Get("Content-Encoding") != "" || !compressable.isCompressable() {
+			rec.writeTo(w, rec.body.Bytes())
+			return
+		}
+
+		key := encoding + ":" + r.URL.Path
+		lastModified := rec.header.Get("Last-Modified")
+
+		mu.RLock()
+		asset, ok := cache[key]
+		mu.RUnlock()
+
+		if !ok || asset.lastModified != lastModified {
+			var buf bytes.Buffer
+			encoder, _, cleanup := c.selectEncoder(http.Header{"Accept-Encoding": {encoding}}, &buf)
+			_, _ = rec.body.WriteTo(encoder)
+			if wc, ok := encoder.(io.Closer); ok {
+				wc.Close()
+			}
+			cleanup()
+
+			asset = compressedAsset{lastModified: lastModified, body: buf.Bytes()}
+			mu.Lock()
+			cache[key] = asset
+			mu.Unlock()
+		}
+
+		rec.header.Set("Content-Encoding", encoding)
+		rec.header.Add("Vary", "Accept-Encoding")
+		rec.header.Set("Content-Length", strconv.Itoa(len(asset.body)))
+		rec.writeTo(w, asset.body)
+	})
+}
+
+// acceptedEncoding returns the name of the highest precedence encoder accepted
+// by the Accept-Encoding request header, or the empty string if there is none.
+func (c *Compressor) acceptedEncoding(h http.Header) string {
+	accepted := strings.Split(strings.ToLower(h.Get("Accept-Encoding")), ",")
+	for _, name := range c.encodingPrecedence {
+		if matchAcceptEncoding(accepted, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// bufferedResponse is a http.ResponseWriter that holds the response in memory
+// until it's written out with writeTo.
+type bufferedResponse struct {
+	header      http.Header
+	body        bytes.Buffer
+	code        int
+	wroteHeader bool
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if !b.wroteHeader {
+		b.code = code
+		b.wroteHeader = true
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// writeTo copies the buffered headers and status to w followed by body.
+func (b *bufferedResponse) writeTo(w http.ResponseWriter, body []byte) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(b.code)
+	_, _ = w.Write(body)
+}
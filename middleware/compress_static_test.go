@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type countingGzipWriter struct {
+	gw *gzip.Writer
+}
+
+func (c *countingGzipWriter) Write(p []byte) (int, error) { return c.gw.Write(p) }
+func (c *countingGzipWriter) Close() error                { return c.gw.Close() }
+
+func TestCompressStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.css":         {Data: []byte("body { color: red; }"), ModTime: time.Unix(1000, 0)},
+		"app.3f2a1b9c.js": {Data: []byte("console.log('fingerprinted')")},
+		"logo.png":        {Data: []byte("not really a png")},
+	}
+
+	compressions := 0
+	compressor := NewCompressor(5, "text/css", "text/javascript")
+	compressor.SetEncoder("gzip", func(w io.Writer, level int) io.Writer {
+		compressions++
+		gw, _ := gzip.NewWriterLevel(w, level)
+		return &countingGzipWriter{gw}
+	})
+	compressions = 0 // ignore the probe made by SetEncoder
+
+	h := compressor.StaticHandler(http.FileServer(http.FS(fsys)))
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		h.ServeHTTP(w, r)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) string {
+		gr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(gr)
+		return string(b)
+	}
+
+	for i := 0; i < 3; i++ {
+		w := get("/app.css", "gzip, deflate")
+		assertEqual(t, "gzip", w.Header().Get("Content-Encoding"))
+		assertEqual(t, "body { color: red; }", decode(w))
+	}
+	assertEqual(t, 1, compressions)
+
+	w := get("/app.css", "")
+	assertEqual(t, "", w.Header().Get("Content-Encoding"))
+	assertEqual(t, "body { color: red; }", w.Body.String())
+
+	w = get("/logo.png", "gzip")
+	assertEqual(t, "", w.Header().Get("Content-Encoding"))
+	assertEqual(t, "not really a png", w.Body.String())
+
+	// A changed modification time invalidates the cached asset
+	fsys["app.css"] = &fstest.MapFile{Data: []byte("body { color: blue; }"), ModTime: time.Unix(2000, 0)}
+	w = get("/app.css", "gzip")
+	assertEqual(t, "body { color: blue; }", decode(w))
+	assertEqual(t, 2, compressions)
+
+	w = get("/app.3f2a1b9c.js", "gzip")
+	assertEqual(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	assertEqual(t, "console.log('fingerprinted')", decode(w))
+	w = get("/app.css", "gzip")
+	assertEqual(t, "", w.Header().Get("Cache-Control"))
+}
+
+func TestIsFingerprinted(t *testing.T) {
+	tests := map[string]bool{
+		"/app.3f2a1b9c.js":         true,
+		"/img/logo-3F2A1B9C0D.svg": true,
+		"/app.css":                 false,
+		"/report-20231015.pdf":     false,
+		"/backup.20240101.tar":     false,
+		"/app.3f2a1b9c/main.js":    false,
+		"/short.3f2a1b.js":         false,
+	}
+	for path, expected := range tests {
+		if isFingerprinted(path) != expected {
+			t.Errorf("isFingerprinted(%q) != %v", path, expected)
+		}
+	}
+}
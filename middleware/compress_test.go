@@ -216,3 +216,69 @@ func decodeResponseBody(t *testing.T, resp *http.Response) string {
 
 	return string(respBody)
 }
+
+func TestCompressDefaultTypes(t *testing.T) {
+	body := strings.Repeat("<p>penguin</p>", 100)
+
+	r := chi.NewRouter()
+	r.Use(Compress(5))
+	r.Get("/html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.Write([]byte(body[:len(body)/2]))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(body[len(body)/2:]))
+	})
+	r.Get("/png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(body))
+	})
+
+	tests := []struct {
+		path, acceptEncoding, encoding string
+	}{
+		{"/html", "gzip", "gzip"},
+		{"/html", "deflate", "deflate"},
+		{"/html", "", ""},
+		{"/png", "gzip", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if enc := w.Header().Get("Content-Encoding"); enc != tt.encoding {
+			t.Fatalf("%s (%s): Content-Encoding %q, expecting %q", tt.path, tt.acceptEncoding, enc, tt.encoding)
+		}
+		var reader io.Reader = w.Body
+		switch tt.encoding {
+		case "gzip":
+			gr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = gr
+		case "deflate":
+			reader = flate.NewReader(w.Body)
+		}
+		if tt.encoding != "" {
+			if w.Header().Get("Content-Length") != "" {
+				t.Fatalf("%s (%s): expecting Content-Length to be removed", tt.path, tt.acceptEncoding)
+			}
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Fatalf("%s (%s): expecting Vary: Accept-Encoding", tt.path, tt.acceptEncoding)
+			}
+			if !w.Flushed {
+				t.Fatalf("%s (%s): expecting Flush to pass through", tt.path, tt.acceptEncoding)
+			}
+		}
+		b, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != body {
+			t.Fatalf("%s (%s): unexpected body %q", tt.path, tt.acceptEncoding, b)
+		}
+	}
+}
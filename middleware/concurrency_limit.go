@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+// ConcurrencyLimit is a middleware that caps the number of requests executing
+// at the same time for each route pattern, answering any request above `max`
+// with a 503 Service Unavailable instead of queueing it. Unlike Throttle it's
+// meant to protect a few expensive endpoints while leaving the rest of the API
+// unthrottled, for example:
+//
+//	limit := middleware.ConcurrencyLimit(2)
+//	r.With(limit).Get("/reports/{id}", generateReport)
+//	r.With(limit).Get("/exports/{id}", generateExport)
+//
+// allows 2 concurrent reports and, separately, 2 concurrent exports. The route
+// pattern is only known once the request is routed, so apply it with With or
+// Group rather than Use on the top-level router, where every route would share
+// the same limit.
+func ConcurrencyLimit(max int) func(http.Handler) http.Handler {
+	if max < 1 {
+		panic("middleware: ConcurrencyLimit expects max > 0")
+	}
+
+	var mu sync.Mutex
+	slots := make(map[string]chan struct{})
+
+	slotsFor := func(pattern string) chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+		s, ok := slots[pattern]
+		if !ok {
+			s = make(chan struct{}, max)
+			slots[pattern] = s
+		}
+		return s
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			var pattern string
+			if rctx := penguin.RouteContext(r.Context()); rctx != nil {
+				pattern = rctx.RoutePattern()
+			}
+
+			s := slotsFor(pattern)
+			select {
+			case s <- struct{}{}:
+				defer func() { <-s }()
+				next.ServeHTTP(w, r)
+			default:
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
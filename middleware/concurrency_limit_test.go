@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	release := make(chan struct{})
+	var started sync.WaitGroup
+
+	blocking := func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		<-release
+		w.Write([]byte("done"))
+	}
+
+	limit := ConcurrencyLimit(1)
+	r := penguin.New()
+	r.With(limit).Get("/reports/{id}", blocking)
+	r.With(limit).Get("/exports/{id}", blocking)
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	var done sync.WaitGroup
+	serve := func(path string) {
+		defer done.Done()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// occupy the single slot of each pattern
+	started.Add(2)
+	done.Add(2)
+	go serve("/reports/1")
+	go serve("/exports/1")
+	started.Wait()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/reports/2", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expecting 503 above the limit, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expecting other routes unthrottled, got %d", w.Code)
+	}
+
+	close(release)
+	done.Wait()
+
+	started.Add(1)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/reports/3", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "done" {
+		t.Fatalf("expecting the slot to be released, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -17,7 +17,9 @@ func SetHeader(key, value string) func(next http.Handler) http.Handler {
 }
 
 // AllowContentType enforces a whitelist of request Content-Types otherwise responds
-// with a 415 Unsupported Media Type status.
+// with a 415 Unsupported Media Type status. Content-Type parameters such as the
+// charset are ignored, and requests without a body or using a bodyless method
+// like GET, HEAD or OPTIONS are always let through.
 func AllowContentType(contentTypes ...string) func(next http.Handler) http.Handler {
 	allowedContentTypes := make(map[string]struct{}, len(contentTypes))
 	for _, ctype := range contentTypes {
@@ -26,7 +28,7 @@ func AllowContentType(contentTypes ...string) func(next http.Handler) http.Handl
 
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			if r.ContentLength == 0 {
+			if r.ContentLength == 0 || bodylessMethod(r.Method) {
 				// skip check for empty content body
 				next.ServeHTTP(w, r)
 				return
@@ -47,3 +49,12 @@ func AllowContentType(contentTypes ...string) func(next http.Handler) http.Handl
 		return http.HandlerFunc(fn)
 	}
 }
+
+// bodylessMethod reports whether requests using method don't carry a body.
+func bodylessMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -79,3 +79,42 @@ func TestContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestContentTypeBodylessMethods(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(AllowContentType("application/json"))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, http.StatusOK, w.Code)
+
+	req := httptest.NewRequest("GET", "/", bytes.NewReader([]byte("a=1")))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertEqual(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("POST", "/", bytes.NewReader([]byte("a=1")))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertEqual(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestSetHeader(t *testing.T) {
+	r := chi.NewRouter()
+	r.With(SetHeader("X-Frame-Options", "DENY"), SetHeader("X-Content-Type-Options", "nosniff")).
+		Get("/secure", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/plain", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/secure", nil))
+	assertEqual(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assertEqual(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/plain", nil))
+	assertEqual(t, "", w.Header().Get("X-Frame-Options"))
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests,
+	// such as https://app.example.com. An origin may contain a single "*"
+	// wildcard, like https://*.example.com, and "*" alone allows any origin.
+	AllowedOrigins []string
+
+	// AllowedMethods are the methods allowed in cross-origin requests. It
+	// defaults to GET, HEAD and POST.
+	AllowedMethods []string
+
+	// AllowedHeaders are the request headers allowed in cross-origin requests.
+	// When empty the headers requested by a preflight request are allowed.
+	AllowedHeaders []string
+
+	// ExposedHeaders are the response headers the browser lets the client
+	// read, on top of the CORS-safelisted ones.
+	ExposedHeaders []string
+
+	// AllowCredentials lets the browser send cookies and HTTP authentication
+	// along with cross-origin requests. The request Origin is then echoed in
+	// Access-Control-Allow-Origin, never "*".
+	AllowCredentials bool
+
+	// MaxAge is how long the browser may cache the result of a preflight
+	// request. Browsers apply their own default when it's zero.
+	MaxAge time.Duration
+}
+
+// CORS is a middleware that implements Cross-Origin Resource Sharing for the
+// origins, methods and headers allowed by opts. Preflight requests, OPTIONS
+// requests carrying an Access-Control-Request-Method header, are answered with
+// a 204 and never reach the router. Requests from an origin that isn't allowed
+// are served without CORS headers, so the browser blocks the response.
+func CORS(opts CORSOptions) func(next http.Handler) http.Handler {
+	methods := []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	if len(opts.AllowedMethods) > 0 {
+		methods = make([]string, len(opts.AllowedMethods))
+		for i, m := range opts.AllowedMethods {
+			methods[i] = strings.ToUpper(m)
+		}
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+
+	anyOrigin := false
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			anyOrigin = true
+		}
+	}
+
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		for _, o := range opts.AllowedOrigins {
+			if matchOrigin(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !allowed(origin) {
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !containsMethod(methods, r.Header.Get("Access-Control-Request-Method")) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+			} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge/time.Second)))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// matchOrigin reports whether origin matches the allowed origin pattern, which
+// may contain a single "*" wildcard.
+func matchOrigin(pattern, origin string) bool {
+	prefix, suffix, wildcard := strings.Cut(strings.ToLower(pattern), "*")
+	origin = strings.ToLower(origin)
+	if !wildcard {
+		return origin == prefix
+	}
+	return len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestCORS(t *testing.T) {
+	r := penguin.New()
+	r.Use(CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "PUT"},
+		AllowedHeaders:   []string{"Content-Type", "X-Token"},
+		ExposedHeaders:   []string{"X-Total"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		name          string
+		method        string
+		origin        string
+		requestMethod string
+		status        int
+		body          string
+		headers       map[string]string
+	}{
+		{
+			"simple request", "GET", "https://app.example.com", "", 200, "ok",
+			map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Total",
+				"Access-Control-Allow-Methods":     "",
+			},
+		},
+		{
+			"wildcard subdomain", "GET", "https://eu.example.org", "", 200, "ok",
+			map[string]string{"Access-Control-Allow-Origin": "https://eu.example.org"},
+		},
+		{
+			"preflight", "OPTIONS", "https://app.example.com", "PUT", 204, "",
+			map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET, PUT",
+				"Access-Control-Allow-Headers": "Content-Type, X-Token",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			"preflight with disallowed method", "OPTIONS", "https://app.example.com", "DELETE", 204, "",
+			map[string]string{"Access-Control-Allow-Methods": ""},
+		},
+		{
+			"disallowed origin", "GET", "https://evil.example.net", "", 200, "ok",
+			map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Credentials": ""},
+		},
+		{
+			"disallowed origin preflight", "OPTIONS", "https://example.org", "GET", 204, "",
+			map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			"same origin", "GET", "", "", 200, "ok",
+			map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, expecting %d %q", tt.name, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+		for k, v := range tt.headers {
+			if got := w.Header().Get(k); got != v {
+				t.Errorf("%s: %s = %q, expecting %q", tt.name, k, got, v)
+			}
+		}
+		if w.Header().Get("Vary") != "Origin" {
+			t.Errorf("%s: expecting Vary: Origin", tt.name)
+		}
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	for _, credentials := range []bool{false, true} {
+		h := CORS(CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: credentials})(http.HandlerFunc(handler))
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", "https://any.example.com")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		expected := "*"
+		if credentials {
+			expected = "https://any.example.com"
+		}
+		assertEqual(t, expected, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// Without AllowedHeaders the requested headers are allowed.
+	h := CORS(CORSOptions{AllowedOrigins: []string{"*"}})(http.HandlerFunc(handler))
+	req := httptest.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assertEqual(t, "X-Custom", w.Header().Get("Access-Control-Allow-Headers"))
+	assertEqual(t, "GET, HEAD, POST", w.Header().Get("Access-Control-Allow-Methods"))
+}
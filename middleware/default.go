@@ -0,0 +1,16 @@
+package middleware
+
+import "github.com/SirMetathyst/go-penguin"
+
+// Default returns a new penguin.Engine using the RequestID, Logger and
+// Recoverer middlewares, in that order, which is what most applications want
+// out of the box. More middlewares can still be added with Use before the
+// first route is registered.
+//
+// It lives in this package rather than next to penguin.New because the
+// middleware package depends on penguin.
+func Default() *penguin.Engine {
+	r := penguin.New()
+	r.Use(RequestID, Logger, Recoverer)
+	return r
+}
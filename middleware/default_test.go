@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	r := Default()
+	r.Use(SetHeader("X-Extra", "yes"))
+	assertEqual(t, 4, len(r.Middlewares()))
+
+	var requestID string
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		requestID = GetReqID(r.Context())
+	})
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, http.StatusOK, w.Code)
+	assertEqual(t, "yes", w.Header().Get("X-Extra"))
+	if requestID == "" {
+		t.Fatal("expecting a request ID")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	assertEqual(t, http.StatusInternalServerError, w.Code)
+}
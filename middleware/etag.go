@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// ETagMaxSize is the largest response body, in bytes, that ETag buffers to
+// compute a tag. Larger responses are streamed to the client untagged.
+var ETagMaxSize = 1 << 20
+
+// ETag is a middleware that buffers successful responses to GET and HEAD
+// requests, tags them with a strong ETag computed from a SHA-256 hash of the
+// body and answers with 304 Not Modified when the If-None-Match request header
+// matches the tag. An ETag header set by the handler is used as is.
+//
+// Responses with a status other than 200 OK, responses larger than
+// ETagMaxSize and responses the handler flushes are streamed untagged. HEAD
+// responses are only tagged when the handler writes the body, which excludes
+// HEAD requests answered by the GET route under Engine.AutoHead.
+func ETag(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ew := &etagWriter{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(ew, r)
+		if ew.passthrough {
+			return
+		}
+
+		h := w.Header()
+		// A HEAD handler that wrote no body leaves nothing to hash.
+		if h.Get("ETag") == "" && (r.Method == http.MethodGet || ew.buf.Len() > 0) {
+			sum := sha256.Sum256(ew.buf.Bytes())
+			h.Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		}
+
+		if etag := h.Get("ETag"); etag != "" && etagMatch(r.Header.Get("If-None-Match"), etag) {
+			h.Del("Content-Type")
+			h.Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(ew.code)
+		_, _ = w.Write(ew.buf.Bytes())
+	}
+	return http.HandlerFunc(fn)
+}
+
+// etagMatch reports whether the If-None-Match header value `inm` matches
+// `etag` using the weak comparison.
+func etagMatch(inm, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(inm, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter is a http.ResponseWriter that buffers a 200 OK response up to
+// ETagMaxSize bytes and passes anything else through to the client.
+type etagWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	passthrough bool
+}
+
+func (ew *etagWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 {
+		ew.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if ew.wroteHeader {
+		return
+	}
+	ew.wroteHeader = true
+	ew.code = code
+	if code != http.StatusOK {
+		ew.passthrough = true
+		ew.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (ew *etagWriter) Write(p []byte) (int, error) {
+	ew.WriteHeader(http.StatusOK)
+	if !ew.passthrough && ew.buf.Len()+len(p) > ETagMaxSize {
+		ew.stream()
+	}
+	if ew.passthrough {
+		return ew.ResponseWriter.Write(p)
+	}
+	return ew.buf.Write(p)
+}
+
+func (ew *etagWriter) Flush() {
+	ew.WriteHeader(http.StatusOK)
+	if !ew.passthrough {
+		ew.stream()
+	}
+	if f, ok := ew.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// stream gives up on tagging the response, writing out what was buffered so
+// far and passing any further writes through.
+func (ew *etagWriter) stream() {
+	ew.passthrough = true
+	ew.ResponseWriter.WriteHeader(ew.code)
+	_, _ = ew.ResponseWriter.Write(ew.buf.Bytes())
+	ew.buf.Reset()
+}
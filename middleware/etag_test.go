@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestETag(t *testing.T) {
+	r := penguin.New()
+	r.Use(ETag)
+	hello := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello "))
+		w.Write([]byte("world"))
+	}
+	r.Get("/", hello)
+	r.Head("/", hello)
+	r.Get("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("nope"))
+	})
+	r.Get("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", ETagMaxSize+1)))
+	})
+	r.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	etag := w.Header().Get("ETag")
+	assertEqual(t, http.StatusOK, w.Code)
+	assertEqual(t, "hello world", w.Body.String())
+	assertEqual(t, `"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`, etag)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertEqual(t, http.StatusNotModified, w.Code)
+	assertEqual(t, "", w.Body.String())
+	assertEqual(t, etag, w.Header().Get("ETag"))
+
+	req = httptest.NewRequest("HEAD", "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertEqual(t, http.StatusNotModified, w.Code)
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertEqual(t, http.StatusOK, w.Code)
+	assertEqual(t, "hello world", w.Body.String())
+
+	for _, path := range []string{"/missing", "/large", "/stream"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Header().Get("ETag") != "" {
+			t.Errorf("%s: expecting no ETag, got %q", path, w.Header().Get("ETag"))
+		}
+		if w.Body.Len() == 0 {
+			t.Errorf("%s: expecting the response body", path)
+		}
+	}
+}
@@ -5,17 +5,23 @@ import (
 	"strings"
 )
 
+var (
+	heartbeatContentType = []string{"text/plain"}
+	heartbeatBody        = []byte(".")
+)
+
 // Heartbeat endpoint middleware useful to setting up a path like
 // `/ping` that load balancers or uptime testing external services
 // can make a request before hitting any routes. It's also convenient
-// to place this above ACL middlewares as well.
+// to place this above ACL middlewares as well. Answering the heartbeat
+// doesn't allocate.
 func Heartbeat(endpoint string) func(http.Handler) http.Handler {
 	f := func(h http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			if (r.Method == "GET" || r.Method == "HEAD") && strings.EqualFold(r.URL.Path, endpoint) {
-				w.Header().Set("Content-Type", "text/plain")
+				w.Header()["Content-Type"] = heartbeatContentType
 				w.WriteHeader(http.StatusOK)
-				w.Write([]byte("."))
+				w.Write(heartbeatBody)
 				return
 			}
 			h.ServeHTTP(w, r)
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestHeartbeat(t *testing.T) {
+	r := penguin.New()
+	r.Use(Heartbeat("/ping"))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("root"))
+	})
+	r.Post("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("posted"))
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/ping", 200, "."},
+		{"HEAD", "/ping", 200, "."},
+		{"GET", "/PING", 200, "."},
+		{"POST", "/ping", 200, "posted"},
+		{"GET", "/", 200, "root"},
+		{"GET", "/ping/", 404, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %d %q, expecting %d %q", tt.method, tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+		if tt.body == "." {
+			assertEqual(t, "text/plain", w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+// discardWriter is a http.ResponseWriter that doesn't allocate.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header         { return w.header }
+func (w discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w discardWriter) WriteHeader(int)             {}
+
+func TestHeartbeatAllocs(t *testing.T) {
+	h := Heartbeat("/ping")(http.NotFoundHandler())
+	w := discardWriter{header: http.Header{}}
+	req := httptest.NewRequest("GET", "/ping", nil)
+
+	if allocs := testing.AllocsPerRun(100, func() { h.ServeHTTP(w, req) }); allocs != 0 {
+		t.Fatalf("expecting no allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceRetryAfter is the delay advertised in the Retry-After header of
+// responses sent by MaintenanceFromFS while maintenance mode is enabled.
+var MaintenanceRetryAfter = 5 * time.Minute
+
+// MaintenanceFromFS is a middleware that, while `enabled` is set, answers every
+// request with the HTML maintenance `page` read from fsys and a 503 Service
+// Unavailable status. Requests for other files in fsys, such as the styles and
+// images referenced by the page, are still served so the page renders styled.
+// Flipping `enabled` back off resumes normal routing, for example:
+//
+//	//go:embed maintenance
+//	var maintenanceFS embed.FS
+//
+//	var down atomic.Bool
+//	sub, _ := fs.Sub(maintenanceFS, "maintenance")
+//	r.Use(middleware.MaintenanceFromFS(&down, sub, "index.html"))
+//
+// It panics if the page can't be read from fsys.
+func MaintenanceFromFS(enabled *atomic.Bool, fsys fs.FS, page string) func(http.Handler) http.Handler {
+	body, err := fs.ReadFile(fsys, page)
+	if err != nil {
+		panic(fmt.Sprintf("middleware: unable to read maintenance page: %v", err))
+	}
+	assets := http.FileServer(http.FS(fsys))
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !enabled.Load() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+				if name != page && isFile(fsys, name) {
+					assets.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("Retry-After", strconv.Itoa(int(MaintenanceRetryAfter/time.Second)))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			if r.Method != http.MethodHead {
+				w.Write(body)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// isFile reports whether name is a regular file in fsys.
+func isFile(fsys fs.FS, name string) bool {
+	if name == "" {
+		return false
+	}
+	fi, err := fs.Stat(fsys, name)
+	return err == nil && !fi.IsDir()
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestMaintenanceFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte(`<link rel="stylesheet" href="/maintenance.css">down`)},
+		"maintenance.css": {Data: []byte("body{}")},
+		"images/logo.png": {Data: []byte("png")},
+	}
+
+	var enabled atomic.Bool
+	r := penguin.New()
+	r.Use(MaintenanceFromFS(&enabled, fsys, "index.html"))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("app"))
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	if resp, body := testRequest(t, ts, "GET", "/", nil); resp.StatusCode != 200 || body != "app" {
+		t.Fatalf("expecting the app while maintenance is off, got %d %q", resp.StatusCode, body)
+	}
+
+	enabled.Store(true)
+
+	resp, body := testRequest(t, ts, "GET", "/", nil)
+	if resp.StatusCode != 503 || body != `<link rel="stylesheet" href="/maintenance.css">down` {
+		t.Fatalf("expecting the maintenance page, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Retry-After") != "300" {
+		t.Fatalf("unexpected Retry-After %q", resp.Header.Get("Retry-After"))
+	}
+	if resp, _ := testRequest(t, ts, "POST", "/api/users", nil); resp.StatusCode != 503 {
+		t.Fatalf("expecting 503 for app routes, got %d", resp.StatusCode)
+	}
+	if resp, body := testRequest(t, ts, "GET", "/maintenance.css", nil); resp.StatusCode != 200 || body != "body{}" {
+		t.Fatalf("expecting the page stylesheet, got %d %q", resp.StatusCode, body)
+	}
+	if resp, body := testRequest(t, ts, "GET", "/images/logo.png", nil); resp.StatusCode != 200 || body != "png" {
+		t.Fatalf("expecting the page image, got %d %q", resp.StatusCode, body)
+	}
+}
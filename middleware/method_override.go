@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+// MethodOverride is a middleware that lets clients which can only send GET and
+// POST requests, such as HTML forms, tunnel other methods through a POST. The
+// method is read from the X-HTTP-Method-Override header, or else the _method
+// form field, and replaces r.Method before routing:
+//
+//	<form method="post" action="/articles/1">
+//		<input type="hidden" name="_method" value="DELETE">
+//	</form>
+//
+// Only POST requests are overridden. A method that isn't known to the router,
+// see penguin.RegisterMethod, is rejected with 400 Bad Request. Reading the
+// form field parses the request body, so place the middleware after any
+// middleware limiting the body size.
+func MethodOverride(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method := r.Header.Get("X-HTTP-Method-Override")
+		if method == "" {
+			method = r.PostFormValue("_method")
+		}
+		if method = strings.TrimSpace(method); method != "" {
+			if !penguin.IsMethod(method) {
+				http.Error(w, "unknown method override "+method, http.StatusBadRequest)
+				return
+			}
+			r.Method = strings.ToUpper(method)
+		}
+
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestMethodOverride(t *testing.T) {
+	r := penguin.New()
+	r.Use(MethodOverride)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	}
+	r.Get("/", handler)
+	r.Post("/", handler)
+	r.Put("/", handler)
+	r.Delete("/", handler)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		header   string
+		form     string
+		status   int
+		expected string
+	}{
+		{"plain post", "POST", "/", "", "", 200, "POST"},
+		{"header override", "POST", "/", "put", "", 200, "PUT"},
+		{"form override", "POST", "/", "", "_method=DELETE", 200, "DELETE"},
+		{"header wins over form", "POST", "/", "PUT", "_method=DELETE", 200, "PUT"},
+		{"unknown method", "POST", "/", "FROB", "", 400, ""},
+		{"get header", "GET", "/", "DELETE", "", 200, "GET"},
+		{"get query", "GET", "/?_method=DELETE", "", "", 200, "GET"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.form))
+		if tt.form != "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if tt.header != "" {
+			req.Header.Set("X-HTTP-Method-Override", tt.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("%s: expecting status %d, got %d", tt.name, tt.status, w.Code)
+		}
+		if tt.status == 200 && w.Body.String() != tt.expected {
+			t.Fatalf("%s: expecting %s, got %s", tt.name, tt.expected, w.Body.String())
+		}
+	}
+}
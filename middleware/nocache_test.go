@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirMetathyst/go-chi/v5"
+)
+
+func TestNoCache(t *testing.T) {
+	var ifNoneMatch string
+
+	r := chi.NewRouter()
+	r.With(NoCache).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = r.Header.Get("If-None-Match")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `"abc"`)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	for k, v := range noCacheHeaders {
+		assertEqual(t, v, w.Header().Get(k))
+	}
+	assertEqual(t, "Thu, 01 Jan 1970 00:00:00 UTC", w.Header().Get("Expires"))
+	assertEqual(t, "", ifNoneMatch)
+}
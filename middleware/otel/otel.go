@@ -0,0 +1,57 @@
+// Package otel provides a middleware tracing requests with OpenTelemetry. It
+// lives in its own package so only applications that trace requests depend on
+// go.opentelemetry.io/otel.
+package otel
+
+import (
+	"net/http"
+
+	"github.com/SirMetathyst/go-penguin"
+	"github.com/SirMetathyst/go-penguin/middleware"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace is a middleware that starts an OpenTelemetry span with `tracer` for
+// each request, continuing any trace propagated in the request headers with
+// the global propagator, see go.opentelemetry.io/otel.SetTextMapPropagator.
+//
+// Once the handler returns the span is named after the matched route pattern,
+// such as "/users/{id}", see penguin.RoutePattern, and carries the method,
+// route and status of the request. Responses with a 5xx status set the span
+// status to error. Requests that match no route keep the "HTTP <method>" name.
+func Trace(tracer trace.Tracer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := otelapi.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attribute.String("http.method", r.Method)),
+			)
+			defer span.End()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r = r.WithContext(ctx)
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				if pattern := penguin.RoutePattern(r.Context()); pattern != "" {
+					span.SetName(pattern)
+					span.SetAttributes(attribute.String("http.route", pattern))
+				}
+				span.SetAttributes(attribute.Int("http.status_code", status))
+				if status >= 500 {
+					span.SetStatus(codes.Error, http.StatusText(status))
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,81 @@
+package otel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	r := penguin.New()
+	r.Use(Trace(tracer))
+	r.Route("/api", func(r penguin.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("user"))
+		})
+		r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users/42", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/fail", nil))
+
+	spans := recorder.Ended()
+	assertEqual(t, 2, len(spans))
+
+	assertEqual(t, "/api/users/{id}", spans[0].Name())
+	assertEqual(t, codes.Unset, spans[0].Status().Code)
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	if v, _ := attrs.Value("http.status_code"); v.AsInt64() != 200 {
+		t.Fatalf("unexpected status attribute %v", v)
+	}
+	if v, _ := attrs.Value("http.route"); v.AsString() != "/api/users/{id}" {
+		t.Fatalf("unexpected route attribute %v", v)
+	}
+
+	assertEqual(t, "/api/fail", spans[1].Name())
+	assertEqual(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestTracePropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	h := Trace(tracer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// The global propagator is a no-op by default, so install one for the
+	// W3C traceparent header.
+	prev := otelapi.GetTextMapPropagator()
+	otelapi.SetTextMapPropagator(propagation.TraceContext{})
+	defer otelapi.SetTextMapPropagator(prev)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assertEqual(t, 1, len(spans))
+	assertEqual(t, "HTTP GET", spans[0].Name())
+	assertEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assertEqual(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func assertEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("expecting values to be equal but got: '%v' and '%v'", a, b)
+	}
+}
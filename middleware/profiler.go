@@ -3,22 +3,26 @@ package middleware
 import (
 	"expvar"
 	"fmt"
-	"github.com/SirMetathyst/go-chi/v5"
 	"net/http"
 	"net/http/pprof"
+
+	"github.com/SirMetathyst/go-penguin"
 )
 
 // Profiler is a convenient subrouter used for mounting net/http/pprof. ie.
 //
 //  func MyService() http.Handler {
-//    r := chi.NewRouter()
+//    r := penguin.New()
 //    // ..middlewares
 //    r.Mount("/debug", middleware.Profiler())
 //    // ..routes
 //    return r
 //  }
+//
+// The pprof index links to its profiles relative to /debug/pprof/, so mount
+// the profiler at /debug.
 func Profiler() http.Handler {
-	r := chi.NewRouter()
+	r := penguin.New()
 	r.Use(NoCache)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
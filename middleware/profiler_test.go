@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestProfiler(t *testing.T) {
+	r := penguin.New()
+	r.Mount("/debug", Profiler())
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, "GET", "/debug/pprof/cmdline", nil)
+	assertEqual(t, http.StatusOK, resp.StatusCode)
+	if body == "" {
+		t.Fatal("expecting the command line of the test binary")
+	}
+
+	resp, body = testRequest(t, ts, "GET", "/debug/pprof/", nil)
+	assertEqual(t, http.StatusOK, resp.StatusCode)
+	if !strings.Contains(body, "goroutine") {
+		t.Fatalf("expecting the pprof index listing, got %q", body)
+	}
+
+	resp, _ = testRequestNoRedirect(t, ts, "GET", "/debug/pprof", nil)
+	assertEqual(t, http.StatusMovedPermanently, resp.StatusCode)
+	assertEqual(t, "/debug/pprof/", resp.Header.Get("Location"))
+
+	resp, body = testRequest(t, ts, "GET", "/debug/vars", nil)
+	assertEqual(t, http.StatusOK, resp.StatusCode)
+	if !strings.Contains(body, "memstats") {
+		t.Fatalf("expecting the expvar listing, got %q", body)
+	}
+}
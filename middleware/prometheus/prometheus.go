@@ -0,0 +1,75 @@
+// Package prometheus provides a middleware recording request metrics with
+// Prometheus. It lives in its own package so only applications that export
+// metrics depend on github.com/prometheus/client_golang.
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+	"github.com/SirMetathyst/go-penguin/middleware"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is a middleware that records Prometheus metrics for each request
+// with `reg`:
+//
+//	http_requests_total{method, route, code}
+//	http_request_duration_seconds{method, route}
+//
+// The route label is the matched route pattern, such as "/users/{id}", see
+// penguin.RoutePattern, which keeps the number of series bounded. Requests
+// that match no route have an empty route label.
+//
+// Using Metrics more than once with the same registry, for example on several
+// sub-routers, shares the same collectors. It panics if `reg` holds other
+// collectors with the same names.
+func Metrics(reg prom.Registerer) func(next http.Handler) http.Handler {
+	requests := registerCollector(reg, prom.NewCounterVec(prom.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "code"}))
+	durations := registerCollector(reg, prom.NewHistogramVec(prom.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests in seconds by method and route.",
+		Buckets: prom.DefBuckets,
+	}, []string{"method", "route"}))
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				route := penguin.RoutePattern(r.Context())
+				requests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+				durations.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// registerCollector registers `c` with `reg`, returning the collector that is
+// already registered instead if there is one.
+func registerCollector[C prom.Collector](reg prom.Registerer, c C) C {
+	if err := reg.Register(c); err != nil {
+		var are prom.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(fmt.Sprintf("prometheus: failed to register metrics: %v", err))
+	}
+	return c
+}
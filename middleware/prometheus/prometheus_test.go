@@ -0,0 +1,75 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prom.NewRegistry()
+
+	r := penguin.New()
+	r.Use(Metrics(reg))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Route("/api", func(r penguin.Router) {
+		r.Use(Metrics(reg))
+		r.Post("/fail", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/fail", nil))
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := make(map[string][]*dto.Metric)
+	for _, mf := range families {
+		metrics[mf.GetName()] = mf.GetMetric()
+	}
+
+	counts := make(map[string]float64)
+	for _, m := range metrics["http_requests_total"] {
+		counts[labels(m)] += m.GetCounter().GetValue()
+	}
+	assertEqual(t, map[string]float64{
+		"code=200,method=GET,route=/users/{id}": 3,
+		// Counted by the Metrics middleware of the router and the sub-router.
+		"code=500,method=POST,route=/api/fail": 2,
+	}, counts)
+
+	samples := make(map[string]uint64)
+	for _, m := range metrics["http_request_duration_seconds"] {
+		samples[labels(m)] += m.GetHistogram().GetSampleCount()
+	}
+	assertEqual(t, uint64(3), samples["method=GET,route=/users/{id}"])
+}
+
+// labels formats the label pairs of a metric as name=value pairs.
+func labels(m *dto.Metric) string {
+	var s string
+	for i, lp := range m.GetLabel() {
+		if i > 0 {
+			s += ","
+		}
+		s += lp.GetName() + "=" + lp.GetValue()
+	}
+	return s
+}
+
+func assertEqual(t *testing.T, a, b interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("expecting values to be equal but got: '%v' and '%v'", a, b)
+	}
+}
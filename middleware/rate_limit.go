@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitIdleTimeout is how long RateLimit keeps the limiter of a client that
+// stopped sending requests. A client returning afterwards starts over with a
+// full bucket.
+var RateLimitIdleTimeout = 10 * time.Minute
+
+// RateLimit is a middleware that limits each client to requestsPerSecond
+// requests on average with bursts of up to `burst` requests, using a token
+// bucket per client. Clients are identified by keyFn, which defaults to the
+// host of r.RemoteAddr, see RealIP to honour proxy headers.
+//
+// Every response carries the X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers, the latter being the number of seconds until the
+// bucket is full again. Requests over the limit are rejected with a 429 Too
+// Many Requests and a Retry-After header.
+func RateLimit(requestsPerSecond float64, burst int, keyFn func(*http.Request) string) func(http.Handler) http.Handler {
+	if burst < 1 {
+		panic("middleware: RateLimit expects burst > 0")
+	}
+	if keyFn == nil {
+		keyFn = remoteHost
+	}
+	l := &rateLimiter{
+		limit:    rate.Limit(requestsPerSecond),
+		burst:    burst,
+		idle:     RateLimitIdleTimeout,
+		limiters: make(map[string]*rateLimiterEntry),
+		now:      time.Now,
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			allowed, remaining, retryAfter, reset := l.allow(keyFn(r))
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(burst))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+			if !allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+type rateLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter holds the token bucket of each client of RateLimit, evicting
+// idle clients lazily, at most once per idle duration.
+type rateLimiter struct {
+	limit rate.Limit
+	burst int
+	idle  time.Duration
+
+	mu        sync.Mutex
+	lastSweep time.Time
+	limiters  map[string]*rateLimiterEntry
+	now       func() time.Time
+}
+
+// allow takes a token from the bucket of client, reporting whether there was
+// one, the tokens left, how long until the next token and until the bucket is
+// full again.
+func (l *rateLimiter) allow(client string) (allowed bool, remaining int, retryAfter, reset time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= l.idle {
+		for k, e := range l.limiters {
+			if now.Sub(e.lastSeen) >= l.idle {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	e, ok := l.limiters[client]
+	if !ok {
+		e = &rateLimiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[client] = e
+	}
+	e.lastSeen = now
+
+	allowed = e.limiter.AllowN(now, 1)
+	tokens := e.limiter.TokensAt(now)
+	if tokens > 0 {
+		remaining = int(tokens)
+	}
+	if l.limit > 0 {
+		reset = time.Duration((float64(l.burst) - tokens) / float64(l.limit) * float64(time.Second))
+		if !allowed {
+			retryAfter = time.Duration((1 - tokens) / float64(l.limit) * float64(time.Second))
+		}
+	}
+	return allowed, remaining, retryAfter, reset
+}
+
+// remoteHost returns the host of r.RemoteAddr.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds returns d in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
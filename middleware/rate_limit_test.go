@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestRateLimit(t *testing.T) {
+	r := penguin.New()
+	r.Use(RateLimit(20, 2, nil))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	get := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("10.0.0.1:1000")
+	assertEqual(t, 200, w.Code)
+	assertEqual(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assertEqual(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assertEqual(t, "1", w.Header().Get("X-RateLimit-Reset"))
+
+	w = get("10.0.0.1:1001")
+	assertEqual(t, 200, w.Code)
+	assertEqual(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = get("10.0.0.1:1002")
+	assertEqual(t, http.StatusTooManyRequests, w.Code)
+	assertEqual(t, "1", w.Header().Get("Retry-After"))
+	assertEqual(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	// Other clients have their own bucket.
+	assertEqual(t, 200, get("10.0.0.2:1000").Code)
+
+	// The bucket refills at 20 requests per second.
+	time.Sleep(100 * time.Millisecond)
+	assertEqual(t, 200, get("10.0.0.1:1003").Code)
+}
+
+func TestRateLimiterEviction(t *testing.T) {
+	now := time.Now()
+	l := &rateLimiter{
+		limit:    1,
+		burst:    1,
+		idle:     time.Minute,
+		limiters: make(map[string]*rateLimiterEntry),
+		now:      func() time.Time { return now },
+	}
+
+	if allowed, _, _, _ := l.allow("a"); !allowed {
+		t.Fatal("expecting the first request to be allowed")
+	}
+	allowed, _, retryAfter, _ := l.allow("a")
+	if allowed || retryAfter != time.Second {
+		t.Fatalf("expecting the second request to wait a second, got %v %v", allowed, retryAfter)
+	}
+
+	now = now.Add(30 * time.Second)
+	l.allow("b")
+	if len(l.limiters) != 2 {
+		t.Fatalf("expecting 2 limiters, got %d", len(l.limiters))
+	}
+
+	now = now.Add(40 * time.Second)
+	l.allow("b")
+	if _, ok := l.limiters["a"]; ok || len(l.limiters) != 1 {
+		t.Fatalf("expecting the idle limiter to be evicted, got %d limiters", len(l.limiters))
+	}
+}
@@ -4,6 +4,7 @@ package middleware
 // https://github.com/zenazn/goji/tree/master/web/middleware
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
@@ -15,7 +16,8 @@ var xRealIP = http.CanonicalHeaderKey("X-Real-IP")
 
 // RealIP is a middleware that sets a http.Request's RemoteAddr to the results
 // of parsing either the True-Client-IP, X-Real-IP or the X-Forwarded-For headers
-// (in that order).
+// (in that order). The first X-Forwarded-For entry that isn't a private address
+// is taken as the client IP.
 //
 // This middleware should be inserted fairly early in the middleware stack to
 // ensure that subsequent layers (e.g., request loggers) which examine the
@@ -47,14 +49,83 @@ func realIP(r *http.Request) string {
 	} else if xrip := r.Header.Get(xRealIP); xrip != "" {
 		ip = xrip
 	} else if xff := r.Header.Get(xForwardedFor); xff != "" {
-		i := strings.Index(xff, ",")
-		if i == -1 {
-			i = len(xff)
-		}
-		ip = xff[:i]
+		ip = firstPublicIP(strings.Split(xff, ","))
 	}
 	if ip == "" || net.ParseIP(ip) == nil {
 		return ""
 	}
 	return ip
 }
+
+// firstPublicIP returns the first of the X-Forwarded-For entries that isn't a
+// private, loopback or link-local address, or the first entry if all of them
+// are.
+func firstPublicIP(entries []string) string {
+	for _, entry := range entries {
+		ip := net.ParseIP(strings.TrimSpace(entry))
+		if ip != nil && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() {
+			return ip.String()
+		}
+	}
+	return strings.TrimSpace(entries[0])
+}
+
+// RealIPFromTrusted is like RealIP but only trusts the headers of requests
+// whose RemoteAddr is in one of the trusted proxy CIDRs, such as 10.0.0.0/8,
+// leaving RemoteAddr untouched otherwise. The X-Forwarded-For chain is walked
+// from the right, skipping the trusted proxies, and the first untrusted entry
+// is taken as the client IP, so a client can't spoof its address by sending a
+// forged X-Forwarded-For header. Without X-Forwarded-For header the X-Real-IP
+// and True-Client-IP headers are used, in that order. It panics if one of the
+// CIDRs fails to parse.
+func RealIPFromTrusted(cidrs ...string) func(http.Handler) http.Handler {
+	trusted := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("middleware: invalid trusted proxy CIDR '%s': %v", cidr, err))
+		}
+		trusted = append(trusted, ipnet)
+	}
+	isTrusted := func(ip net.IP) bool {
+		for _, ipnet := range trusted {
+			if ipnet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if peer := net.ParseIP(remoteHost(r)); peer != nil && isTrusted(peer) {
+				if rip := trustedRealIP(r, isTrusted); rip != "" {
+					r.RemoteAddr = rip
+				}
+			}
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func trustedRealIP(r *http.Request, isTrusted func(net.IP) bool) string {
+	if xff := r.Header.Values(xForwardedFor); len(xff) > 0 {
+		entries := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(entries) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(entries[i]))
+			if ip == nil {
+				return ""
+			}
+			if !isTrusted(ip) || i == 0 {
+				return ip.String()
+			}
+		}
+	}
+	for _, header := range []string{xRealIP, trueClientIP} {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(header))); ip != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
@@ -112,3 +112,45 @@ func TestIvalidIP(t *testing.T) {
 		t.Fatal("Invalid IP used.")
 	}
 }
+
+func TestXForwardForSkipsPrivateIPs(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-For", "10.1.2.3, 192.168.0.1, 203.0.113.9, 198.51.100.1")
+	assertEqual(t, "203.0.113.9", realIP(req))
+
+	req.Header.Set("X-Forwarded-For", "10.1.2.3, 192.168.0.1")
+	assertEqual(t, "10.1.2.3", realIP(req))
+}
+
+func TestRealIPFromTrusted(t *testing.T) {
+	var remoteAddr string
+	h := RealIPFromTrusted("10.0.0.0/8", "192.168.1.0/24")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		{"single entry", "10.0.0.1:4000", http.Header{"X-Forwarded-For": {"203.0.113.9"}}, "203.0.113.9"},
+		{"chain of trusted proxies", "10.0.0.1:4000", http.Header{"X-Forwarded-For": {"203.0.113.9, 192.168.1.7, 10.2.3.4"}}, "203.0.113.9"},
+		{"spoofed leftmost entry", "10.0.0.1:4000", http.Header{"X-Forwarded-For": {"1.2.3.4, 203.0.113.9, 10.2.3.4"}}, "203.0.113.9"},
+		{"multiple headers", "10.0.0.1:4000", http.Header{"X-Forwarded-For": {"203.0.113.9", "10.2.3.4"}}, "203.0.113.9"},
+		{"all trusted", "10.0.0.1:4000", http.Header{"X-Forwarded-For": {"10.9.9.9, 10.2.3.4"}}, "10.9.9.9"},
+		{"x-real-ip", "10.0.0.1:4000", http.Header{"X-Real-Ip": {"203.0.113.9"}}, "203.0.113.9"},
+		{"untrusted proxy", "198.51.100.1:4000", http.Header{"X-Forwarded-For": {"203.0.113.9"}}, "198.51.100.1:4000"},
+		{"untrusted proxy x-real-ip", "198.51.100.1:4000", http.Header{"X-Real-Ip": {"203.0.113.9"}}, "198.51.100.1:4000"},
+		{"invalid entry", "10.0.0.1:4000", http.Header{"X-Forwarded-For": {"203.0.113.9, nope"}}, "10.0.0.1:4000"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header = tt.header
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if remoteAddr != tt.expected {
+			t.Errorf("%s: RemoteAddr = %q, expecting %q", tt.name, remoteAddr, tt.expected)
+		}
+	}
+}
@@ -20,30 +20,58 @@ import (
 //
 // Alternatively, look at https://github.com/go-chi/httplog middleware pkgs.
 func Recoverer(next http.Handler) http.Handler {
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rvr := recover(); rvr != nil {
-				if rvr == http.ErrAbortHandler {
-					// we don't recover http.ErrAbortHandler so the response
-					// to the client is aborted, this should not be logged
-					panic(rvr)
-				}
+	return RecovererWithOptions(RecovererOptions{PrintStack: true})(next)
+}
+
+// RecovererOptions represents a set of Recoverer options.
+type RecovererOptions struct {
+	// PanicHandler responds to the request whose handler panicked with the
+	// `recovered` value, such as by rendering an error page or logging the
+	// panic. It's called before anything else is written to the response, but
+	// can't undo what the panicking handler already wrote. It defaults to
+	// responding with a 500.
+	PanicHandler func(w http.ResponseWriter, r *http.Request, recovered any)
+
+	// PrintStack logs the panic and its backtrace, to the request's log entry
+	// if there is one, see Logger, otherwise to stderr.
+	PrintStack bool
+}
 
-				logEntry := GetLogEntry(r)
-				if logEntry != nil {
-					logEntry.Panic(rvr, debug.Stack())
-				} else {
-					PrintPrettyStack(rvr)
+// RecovererWithOptions is a middleware that recovers from panics using passed
+// RecovererOptions. See Recoverer.
+func RecovererWithOptions(opts RecovererOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rvr := recover(); rvr != nil {
+					if rvr == http.ErrAbortHandler {
+						// we don't recover http.ErrAbortHandler so the response
+						// to the client is aborted, this should not be logged
+						panic(rvr)
+					}
+
+					if opts.PrintStack {
+						logEntry := GetLogEntry(r)
+						if logEntry != nil {
+							logEntry.Panic(rvr, debug.Stack())
+						} else {
+							PrintPrettyStack(rvr)
+						}
+					}
+
+					if opts.PanicHandler != nil {
+						opts.PanicHandler(w, r, rvr)
+						return
+					}
+					w.WriteHeader(http.StatusInternalServerError)
 				}
+			}()
 
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-		}()
+			next.ServeHTTP(w, r)
+		}
 
-		next.ServeHTTP(w, r)
+		return http.HandlerFunc(fn)
 	}
-
-	return http.HandlerFunc(fn)
 }
 
 // for ability to test the PrintPrettyStack function
@@ -64,3 +64,44 @@ func TestRecovererAbortHandler(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 }
+
+func TestRecovererWithOptions(t *testing.T) {
+	oldRecovererErrorWriter := recovererErrorWriter
+	defer func() { recovererErrorWriter = oldRecovererErrorWriter }()
+	buf := &bytes.Buffer{}
+	recovererErrorWriter = buf
+
+	var recovered any
+	r := chi.NewRouter()
+	r.Use(RecovererWithOptions(RecovererOptions{
+		PanicHandler: func(w http.ResponseWriter, r *http.Request, rvr any) {
+			recovered = rvr
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<p>something went wrong</p>"))
+		},
+	}))
+	r.Get("/", panicingHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assertEqual(t, http.StatusServiceUnavailable, w.Code)
+	assertEqual(t, "<p>something went wrong</p>", w.Body.String())
+	assertEqual(t, "foo", recovered)
+	if buf.Len() != 0 {
+		t.Fatalf("expecting no stack with PrintStack disabled, got %q", buf.String())
+	}
+
+	r = chi.NewRouter()
+	r.Use(RecovererWithOptions(RecovererOptions{PrintStack: true}))
+	r.Get("/", panicingHandler)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assertEqual(t, http.StatusInternalServerError, w.Code)
+	if !strings.Contains(buf.String(), "panicingHandler") {
+		t.Fatalf("expecting the stack to be printed, got %q", buf.String())
+	}
+}
@@ -12,13 +12,13 @@ import (
 	"os"
 	"strings"
 	"sync/atomic"
-)
 
-// Key to use when setting the request ID.
-type ctxKeyRequestID int
+	"github.com/SirMetathyst/go-penguin"
+)
 
 // RequestIDKey is the key that holds the unique request ID in a request context.
-const RequestIDKey ctxKeyRequestID = 0
+// It is shared with the penguin package so routers can read the request ID.
+var RequestIDKey = penguin.RequestIDKey
 
 // RequestIDHeader is the name of the HTTP Header which contains the request id.
 // Exported so that it can be changed by developers
@@ -65,29 +65,33 @@ func init() {
 // process, and where the last number is an atomically incremented request
 // counter.
 func RequestID(next http.Handler) http.Handler {
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		requestID := r.Header.Get(RequestIDHeader)
-		if requestID == "" {
-			myid := atomic.AddUint64(&reqid, 1)
-			requestID = fmt.Sprintf("%s-%06d", prefix, myid)
+	return RequestIDWithGenerator(func() string {
+		return fmt.Sprintf("%s-%06d", prefix, NextRequestID())
+	})(next)
+}
+
+// RequestIDWithGenerator is like RequestID but generates the request IDs with
+// gen, such as a ULID generator. The request ID of the RequestIDHeader request
+// header is kept when present, gen is only called when it's absent.
+func RequestIDWithGenerator(gen func() string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = gen()
+			}
+			ctx = context.WithValue(ctx, RequestIDKey, requestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		}
-		ctx = context.WithValue(ctx, RequestIDKey, requestID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		return http.HandlerFunc(fn)
 	}
-	return http.HandlerFunc(fn)
 }
 
 // GetReqID returns a request ID from the given context if one is present.
 // Returns the empty string if a request ID cannot be found.
 func GetReqID(ctx context.Context) string {
-	if ctx == nil {
-		return ""
-	}
-	if reqID, ok := ctx.Value(RequestIDKey).(string); ok {
-		return reqID
-	}
-	return ""
+	return penguin.RequestIDFromCtx(ctx)
 }
 
 // NextRequestID generates the next request ID in the sequence.
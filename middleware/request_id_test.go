@@ -68,3 +68,28 @@ func TestRequestID(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestIDWithGenerator(t *testing.T) {
+	calls := 0
+	gen := func() string {
+		calls++
+		return fmt.Sprintf("01HZY%d", calls)
+	}
+
+	r := chi.NewRouter()
+	r.Use(RequestIDWithGenerator(gen))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(GetReqID(r.Context())))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, "01HZY1", w.Body.String())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "incoming-1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assertEqual(t, "incoming-1", w.Body.String())
+	assertEqual(t, 1, calls)
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// RequestSize is a middleware that limits the request body to maxBytes bytes.
+// Requests declaring a larger Content-Length are rejected with a 413 Request
+// Entity Too Large up front. Otherwise reading past the limit fails with a
+// *http.MaxBytesError, which penguin.Bind and friends answer with a 413, and a
+// handler that doesn't write a response after hitting the limit gets the 413
+// written for it. Use it with Router.With to set a limit per route:
+//
+//	r.With(middleware.RequestSize(1<<20)).Post("/upload", upload)
+func RequestSize(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxBytes)}
+			r.Body = body
+			ww := NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			if body.exceeded && ww.Status() == 0 {
+				http.Error(ww, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// limitedBody records whether reading the request body hit the RequestSize
+// limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
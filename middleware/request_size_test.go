@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestRequestSize(t *testing.T) {
+	r := penguin.New()
+	r.With(RequestSize(16)).Post("/read", func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			// RequestSize answers for the handler.
+			return
+		}
+		w.Write(b)
+	})
+	r.With(RequestSize(16)).Post("/bind", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]string
+		if err := penguin.Bind(r, &v); err != nil {
+			penguin.Error(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(v)
+	})
+	r.Post("/unlimited", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Write(b)
+	})
+
+	tests := []struct {
+		name       string
+		path, body string
+		chunked    bool
+		status     int
+	}{
+		{"within limit", "/read", "small body", false, 200},
+		{"declared oversized body", "/read", strings.Repeat("x", 17), false, 413},
+		{"undeclared oversized body", "/read", strings.Repeat("x", 100), true, 413},
+		{"bind within limit", "/bind", `{"a":"b"}`, false, 200},
+		{"bind oversized body", "/bind", `{"a":"` + strings.Repeat("x", 100) + `"}`, true, 413},
+		{"other routes", "/unlimited", strings.Repeat("x", 100), false, 200},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: got %d, expecting %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// ContentTypeCheck is the action taken by RequireContentType when a handler
+// writes a response body without setting a Content-Type header.
+type ContentTypeCheck int
+
+const (
+	// ContentTypeCheckOff disables the check, RequireContentType is a no-op.
+	ContentTypeCheckOff ContentTypeCheck = iota
+
+	// ContentTypeCheckWarn prints a warning for each offending response.
+	ContentTypeCheckWarn
+
+	// ContentTypeCheckPanic panics for each offending response, which makes
+	// the check fail loudly in tests.
+	ContentTypeCheckPanic
+)
+
+// RequireContentTypeMode controls the behaviour of RequireContentType. It
+// defaults to ContentTypeCheckOff so the middleware costs nothing in
+// production, and must be set before the routes are defined.
+var RequireContentTypeMode = ContentTypeCheckOff
+
+// for ability to test the RequireContentType warnings
+var requireContentTypeWriter io.Writer = os.Stderr
+
+// RequireContentType is a development safety check that reports handlers
+// which write a response body without setting a Content-Type header, and so
+// rely on net/http sniffing the content type instead. Responses that don't
+// use one of the response helpers are the usual culprit.
+//
+// The check is controlled by RequireContentTypeMode and is disabled by default.
+func RequireContentType(next http.Handler) http.Handler {
+	mode := RequireContentTypeMode
+	if mode == ContentTypeCheckOff {
+		return next
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ww := NewWrapResponseWriter(w, r.ProtoMajor)
+
+		var committed, hasType bool
+		ww.(interface{ basic() *basicWriter }).basic().onWriteHeader = func(code int) {
+			_, hasType = ww.Header()["Content-Type"]
+			committed = true
+		}
+
+		next.ServeHTTP(ww, r)
+
+		if !committed || hasType || ww.BytesWritten() == 0 {
+			return
+		}
+
+		msg := fmt.Sprintf("middleware: %s %s wrote a response body without a Content-Type", r.Method, r.URL.Path)
+		if mode == ContentTypeCheckPanic {
+			panic(msg)
+		}
+		fmt.Fprintln(requireContentTypeWriter, msg)
+	}
+	return http.HandlerFunc(fn)
+}
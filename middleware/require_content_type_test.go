@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireContentType(t *testing.T) {
+	defer func(mode ContentTypeCheck) { RequireContentTypeMode = mode }(RequireContentTypeMode)
+	defer func(w io.Writer) { requireContentTypeWriter = w }(requireContentTypeWriter)
+
+	untyped := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<p>sniffed</p>"))
+	})
+	typed := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<p>typed</p>"))
+	})
+	empty := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var buf bytes.Buffer
+	requireContentTypeWriter = &buf
+
+	RequireContentTypeMode = ContentTypeCheckOff
+	RequireContentType(untyped).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, "", buf.String())
+
+	RequireContentTypeMode = ContentTypeCheckWarn
+	RequireContentType(typed).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	RequireContentType(empty).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, "", buf.String())
+
+	w := httptest.NewRecorder()
+	RequireContentType(untyped).ServeHTTP(w, httptest.NewRequest("GET", "/page", nil))
+	assertEqual(t, "<p>sniffed</p>", w.Body.String())
+	if !strings.Contains(buf.String(), "GET /page wrote a response body without a Content-Type") {
+		t.Fatalf("expecting a warning, got %q", buf.String())
+	}
+
+	RequireContentTypeMode = ContentTypeCheckPanic
+	defer func() {
+		if rvr := recover(); rvr == nil {
+			t.Fatal("expecting RequireContentType to panic")
+		}
+	}()
+	RequireContentType(untyped).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
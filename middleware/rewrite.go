@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+type rewriteRule struct {
+	rex         *regexp.Regexp
+	replacement string
+}
+
+// Rewrite is a middleware that rewrites the routing path of a request using a
+// set of regular expression rules before the router searches for a handler,
+// which is useful to keep legacy links working after a URL migration. The
+// replacement may refer to capture groups of the pattern, for example:
+//
+//	r.Use(middleware.Rewrite(map[string]string{
+//		`/old/(.*)`:             "/new/$1",
+//		`/users/(?P<id>\d+)/me`: "/profile/${id}",
+//	}))
+//
+// Patterns always match the whole path and are tried in sorted order, the
+// first pattern to match wins.
+//
+// Only the routing path is rewritten, r.URL is left untouched so loggers still
+// see the URL the client requested. When used inside a mounted sub-router the
+// rules match against the routing path relative to the mount point, and the
+// rewritten path is routed by that sub-router only.
+func Rewrite(rules map[string]string) func(http.Handler) http.Handler {
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	compiled := make([]rewriteRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rex, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			panic(fmt.Sprintf("middleware: invalid rewrite pattern '%s': %v", pattern, err))
+		}
+		compiled = append(compiled, rewriteRule{rex, rules[pattern]})
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			rctx := penguin.RouteContext(r.Context())
+
+			path := r.URL.Path
+			if r.URL.RawPath != "" {
+				path = r.URL.RawPath
+			}
+			if rctx != nil && rctx.RoutePath != "" {
+				path = rctx.RoutePath
+			}
+
+			for _, rule := range compiled {
+				if !rule.rex.MatchString(path) {
+					continue
+				}
+				path = rule.rex.ReplaceAllString(path, rule.replacement)
+				if rctx != nil {
+					rctx.RoutePath = path
+				} else {
+					r.URL.Path = path
+				}
+				break
+			}
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
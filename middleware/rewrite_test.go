@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestRewrite(t *testing.T) {
+	r := penguin.New()
+	r.Use(Rewrite(map[string]string{
+		`/old/(.*)`:             "/new/$1",
+		`/users/(?P<id>\d+)/me`: "/profile/${id}",
+	}))
+	r.Get("/new/{x}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new " + penguin.URLParam(r, "x") + " from " + r.URL.Path))
+	})
+	r.Get("/profile/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("profile " + penguin.URLParam(r, "id")))
+	})
+	r.Route("/api", func(r penguin.Router) {
+		r.Use(Rewrite(map[string]string{`/v1/(.*)`: "/v2/$1"}))
+		r.Get("/v2/{x}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("v2 " + penguin.URLParam(r, "x")))
+		})
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	if _, body := testRequest(t, ts, "GET", "/old/thing", nil); body != "new thing from /old/thing" {
+		t.Fatalf(body)
+	}
+	if _, body := testRequest(t, ts, "GET", "/new/thing", nil); body != "new thing from /new/thing" {
+		t.Fatalf(body)
+	}
+	if _, body := testRequest(t, ts, "GET", "/users/42/me", nil); body != "profile 42" {
+		t.Fatalf(body)
+	}
+	if resp, _ := testRequest(t, ts, "GET", "/users/abc/me", nil); resp.StatusCode != 404 {
+		t.Fatalf("expecting 404, got %d", resp.StatusCode)
+	}
+	if _, body := testRequest(t, ts, "GET", "/api/v1/thing", nil); body != "v2 thing" {
+		t.Fatalf(body)
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+var defaultSequenceIdleTimeout = 10 * time.Minute
+
+// SequenceStore records the last sequence number seen for each client.
+type SequenceStore interface {
+	// Advance records seq as the last sequence number of client and returns
+	// true if seq is greater than the one previously recorded, or if client
+	// has no sequence number recorded. It returns false otherwise and leaves
+	// the recorded sequence number untouched.
+	Advance(client string, seq uint64) bool
+}
+
+// SequenceGuardOpts represents a set of sequence guard options.
+type SequenceGuardOpts struct {
+	// Header is the request header carrying the sequence number.
+	Header string
+
+	// ClientKeyFn identifies the client sending the request, defaults to
+	// penguin.ClientIP.
+	ClientKeyFn func(r *http.Request) string
+
+	// Store records the last sequence number of each client, defaults to a
+	// MemorySequenceStore forgetting clients idle for 10 minutes.
+	Store SequenceStore
+}
+
+// SequenceGuard is a middleware that rejects requests replayed or received out
+// of order, such as retries of a request that already succeeded, by reading a
+// monotonic per-client sequence number from the `header` request header.
+//
+// The first request of a client is accepted with any sequence number, after
+// that a request is only accepted if its sequence number is greater than the
+// last accepted one, otherwise it's rejected with a 409 Conflict. Requests
+// without a valid sequence number are rejected with a 400 Bad Request.
+func SequenceGuard(header string) func(http.Handler) http.Handler {
+	return SequenceGuardWithOpts(SequenceGuardOpts{Header: header})
+}
+
+// SequenceGuardWithOpts is a middleware that rejects replayed or out of order
+// requests using passed SequenceGuardOpts. See SequenceGuard.
+func SequenceGuardWithOpts(opts SequenceGuardOpts) func(http.Handler) http.Handler {
+	if opts.Header == "" {
+		panic("middleware: SequenceGuard expects a header name")
+	}
+	if opts.ClientKeyFn == nil {
+		opts.ClientKeyFn = penguin.ClientIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemorySequenceStore(defaultSequenceIdleTimeout)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			seq, err := strconv.ParseUint(r.Header.Get(opts.Header), 10, 64)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			if !opts.Store.Advance(opts.ClientKeyFn(r), seq) {
+				http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+type sequenceEntry struct {
+	seq      uint64
+	lastSeen time.Time
+}
+
+// MemorySequenceStore is an in-memory SequenceStore that forgets clients after
+// they've been idle for a while, so they may start over with any sequence
+// number.
+type MemorySequenceStore struct {
+	mu        sync.Mutex
+	idle      time.Duration
+	lastSweep time.Time
+	clients   map[string]sequenceEntry
+	now       func() time.Time
+}
+
+// NewMemorySequenceStore returns a MemorySequenceStore forgetting clients that
+// haven't sent a request for the `idle` duration.
+func NewMemorySequenceStore(idle time.Duration) *MemorySequenceStore {
+	return &MemorySequenceStore{
+		idle:    idle,
+		clients: make(map[string]sequenceEntry),
+		now:     time.Now,
+	}
+}
+
+// Advance implements SequenceStore. Idle clients are evicted lazily, at most
+// once per idle duration.
+func (s *MemorySequenceStore) Advance(client string, seq uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= s.idle {
+		for k, e := range s.clients {
+			if now.Sub(e.lastSeen) >= s.idle {
+				delete(s.clients, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	e, ok := s.clients[client]
+	if ok && now.Sub(e.lastSeen) < s.idle && seq <= e.seq {
+		return false
+	}
+	s.clients[client] = sequenceEntry{seq: seq, lastSeen: now}
+	return true
+}
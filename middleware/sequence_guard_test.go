@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestSequenceGuard(t *testing.T) {
+	r := penguin.New()
+	r.Use(SequenceGuardWithOpts(SequenceGuardOpts{
+		Header:      "X-Sequence",
+		ClientKeyFn: func(r *http.Request) string { return r.Header.Get("X-Client") },
+	}))
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		client, seq string
+		expected    int
+	}{
+		{"a", "5", 200}, // first request of a client accepts any sequence
+		{"a", "6", 200},
+		{"a", "6", 409}, // replayed
+		{"a", "4", 409}, // out of order
+		{"b", "1", 200}, // clients are tracked separately
+		{"a", "10", 200},
+		{"a", "", 400},
+		{"a", "-1", 400},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.Header.Set("X-Client", tt.client)
+		if tt.seq != "" {
+			req.Header.Set("X-Sequence", tt.seq)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.expected {
+			t.Errorf("request %d (client %s, seq %q): status %d, expecting %d", i, tt.client, tt.seq, w.Code, tt.expected)
+		}
+	}
+}
+
+func TestMemorySequenceStoreEviction(t *testing.T) {
+	now := time.Now()
+	s := NewMemorySequenceStore(time.Minute)
+	s.now = func() time.Time { return now }
+
+	if !s.Advance("a", 5) || s.Advance("a", 5) {
+		t.Fatal("expecting the second request to be rejected")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !s.Advance("b", 1) {
+		t.Fatal("expecting a new client to be accepted")
+	}
+	if _, ok := s.clients["a"]; ok {
+		t.Fatal("expecting the idle client to be evicted")
+	}
+	if !s.Advance("a", 1) {
+		t.Fatal("expecting an evicted client to start over")
+	}
+}
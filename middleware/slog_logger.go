@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SlogLogger is a middleware that logs one structured record per request with
+// logger, carrying the method, path, status, bytes written, duration, remote
+// IP and request ID, if any, see RequestID. Responses with a 5xx status are
+// logged at the error level, others at the info level.
+func SlogLogger(logger *slog.Logger) func(next http.Handler) http.Handler {
+	return SlogLoggerWithLevel(logger, func(status int) slog.Level {
+		if status >= 500 {
+			return slog.LevelError
+		}
+		return slog.LevelInfo
+	})
+}
+
+// SlogLoggerWithLevel is like SlogLogger but logs each request at the level
+// returned by levelFn for the response status.
+func SlogLoggerWithLevel(logger *slog.Logger, levelFn func(status int) slog.Level) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", status),
+					slog.Int("bytes", ww.BytesWritten()),
+					slog.Duration("duration", time.Since(start)),
+					slog.String("remote_ip", remoteHost(r)),
+				}
+				if reqID := GetReqID(r.Context()); reqID != "" {
+					attrs = append(attrs, slog.String("request_id", reqID))
+				}
+				logger.LogAttrs(r.Context(), levelFn(status), "request", attrs...)
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
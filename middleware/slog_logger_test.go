@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+// recordingHandler is a slog.Handler keeping the records it handles.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+func TestSlogLogger(t *testing.T) {
+	h := &recordingHandler{}
+
+	r := penguin.New()
+	r.Use(RequestID)
+	r.Use(SlogLogger(slog.New(h)))
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	for _, path := range []string{"/ok", "/fail"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set(RequestIDHeader, "req-1")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(h.records) != 2 {
+		t.Fatalf("expecting a record per request, got %d", len(h.records))
+	}
+
+	tests := []struct {
+		level slog.Level
+		attrs map[string]any
+	}{
+		{slog.LevelInfo, map[string]any{"method": "GET", "path": "/ok", "status": int64(200), "bytes": int64(5), "remote_ip": "10.0.0.1", "request_id": "req-1"}},
+		{slog.LevelError, map[string]any{"path": "/fail", "status": int64(502), "bytes": int64(0)}},
+	}
+	for i, tt := range tests {
+		rec := h.records[i]
+		if rec.Level != tt.level {
+			t.Errorf("record %d: level %v, expecting %v", i, rec.Level, tt.level)
+		}
+		got := map[string]any{}
+		rec.Attrs(func(a slog.Attr) bool {
+			got[a.Key] = a.Value.Any()
+			return true
+		})
+		for k, v := range tt.attrs {
+			if got[k] != v {
+				t.Errorf("record %d: %s = %v (%T), expecting %v", i, k, got[k], got[k], v)
+			}
+		}
+		if _, ok := got["duration"].(time.Duration); !ok {
+			t.Errorf("record %d: expecting a duration, got %v", i, got["duration"])
+		}
+	}
+}
+
+func TestSlogLoggerWithLevel(t *testing.T) {
+	h := &recordingHandler{}
+	mw := SlogLoggerWithLevel(slog.New(h), func(status int) slog.Level {
+		if status >= 400 {
+			return slog.LevelWarn
+		}
+		return slog.LevelDebug
+	})
+	mw(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, slog.LevelWarn, h.records[0].Level)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+// TemplateData is a middleware that stores the data returned by fn, such as the
+// current user, navigation or a CSRF token, as the base template data of the
+// request. penguin.HTML merges it with the data of each render, the keys passed
+// to the render winning:
+//
+//	r.Use(middleware.TemplateData(func(r *http.Request) penguin.M {
+//		return penguin.M{"user": currentUser(r), "nav": nav}
+//	}))
+//
+// Stacked TemplateData middlewares merge their data, the innermost winning.
+func TemplateData(fn func(r *http.Request) penguin.M) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		h := func(w http.ResponseWriter, r *http.Request) {
+			ctx := penguin.WithTemplateData(r.Context(), fn(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(h)
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestTemplateData(t *testing.T) {
+	r := penguin.New()
+	r.HTML(template.Must(template.New("").Parse(`{{define "page"}}{{.user}}|{{.title}}|{{.nav}}{{end}}`)))
+	r.Use(TemplateData(func(r *http.Request) penguin.M {
+		return penguin.M{"user": "jo", "title": "Base", "nav": "home"}
+	}))
+	r.Use(TemplateData(func(r *http.Request) penguin.M {
+		return penguin.M{"nav": r.URL.Path}
+	}))
+	r.Get("/merged", func(w http.ResponseWriter, r *http.Request) {
+		penguin.HTML(w, r, 200, "page", penguin.M{"title": "Page"})
+	})
+	r.Get("/nil", func(w http.ResponseWriter, r *http.Request) {
+		penguin.HTML(w, r, 200, "page", nil)
+	})
+
+	tests := map[string]string{
+		"/merged": "jo|Page|/merged",
+		"/nil":    "jo|Base|/nil",
+	}
+	for path, expected := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assertEqual(t, expected, w.Body.String())
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -15,35 +16,137 @@ import (
 //
 // ie. a route/handler may look like:
 //
-//  r.Get("/long", func(w http.ResponseWriter, r *http.Request) {
-// 	 ctx := r.Context()
-// 	 processTime := time.Duration(rand.Intn(4)+1) * time.Second
+//	r.Get("/long", func(w http.ResponseWriter, r *http.Request) {
+//		ctx := r.Context()
+//		processTime := time.Duration(rand.Intn(4)+1) * time.Second
 //
-// 	 select {
-// 	 case <-ctx.Done():
-// 	 	return
+//		select {
+//		case <-ctx.Done():
+//			return
 //
-// 	 case <-time.After(processTime):
-// 	 	 // The above channel simulates some hard work.
-// 	 }
+//		case <-time.After(processTime):
+//			// The above channel simulates some hard work.
+//		}
 //
-// 	 w.Write([]byte("done"))
-//  })
+//		w.Write([]byte("done"))
+//	})
 //
+// The 504 is sent at the deadline if the handler hasn't started writing its
+// response by then, after which anything it writes is discarded and its
+// writes return http.ErrHandlerTimeout. A handler that already started writing
+// is left to complete its response. Either way the middleware only returns once
+// the handler does.
 func Timeout(timeout time.Duration) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
-			defer func() {
-				cancel()
-				if ctx.Err() == context.DeadlineExceeded {
-					w.WriteHeader(http.StatusGatewayTimeout)
-				}
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, h: make(http.Header), ctx: ctx}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+					close(done)
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
 			}()
 
-			r = r.WithContext(ctx)
-			next.ServeHTTP(w, r)
+			select {
+			case <-done:
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.checkTimeout()
+				tw.mu.Unlock()
+
+				// The request and its routing context stay in use until the
+				// handler returns.
+				<-done
+			}
+
+			// The handler may have returned without writing after the deadline.
+			tw.mu.Lock()
+			tw.checkTimeout()
+			tw.mu.Unlock()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			default:
+			}
 		}
 		return http.HandlerFunc(fn)
 	}
 }
+
+// timeoutWriter is the http.ResponseWriter passed to the handler by Timeout,
+// which drops the handler's writes once the 504 has been sent. The handler
+// gets its own header map, as it may still be writing to it after the 504.
+type timeoutWriter struct {
+	w   http.ResponseWriter
+	h   http.Header
+	ctx context.Context
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeader(code)
+}
+
+// checkTimeout sends the 504 if the deadline passed before the handler wrote
+// its header. It's called by whichever of the middleware and the handler
+// notices the deadline first.
+func (tw *timeoutWriter) checkTimeout() {
+	if tw.timedOut || tw.wroteHeader || tw.ctx.Err() != context.DeadlineExceeded {
+		return
+	}
+	tw.timedOut = true
+	tw.w.Header().Set("Content-Length", "0")
+	tw.w.WriteHeader(http.StatusGatewayTimeout)
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.checkTimeout()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeader(http.StatusOK)
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return tw.w.Write(p)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if f, ok := tw.w.(http.Flusher); ok && !tw.timedOut {
+		f.Flush()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+func TestTimeout(t *testing.T) {
+	lateWrite := make(chan error, 1)
+
+	r := penguin.New()
+	r.Use(Timeout(50 * time.Millisecond))
+	r.Get("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Fast", "yes")
+		w.Write([]byte("fast"))
+	})
+	r.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Header().Set("X-Slow", "yes")
+		_, err := w.Write([]byte("too late"))
+		lateWrite <- err
+	})
+	r.Get("/returns", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		lateWrite <- http.ErrHandlerTimeout
+	})
+	r.Get("/ignores-ctx", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, err := w.Write([]byte("too late"))
+		lateWrite <- err
+	})
+	r.Get("/streaming", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("started"))
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(" and finished"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	assertEqual(t, 200, w.Code)
+	assertEqual(t, "fast", w.Body.String())
+	assertEqual(t, "yes", w.Header().Get("X-Fast"))
+
+	for _, path := range []string{"/slow", "/returns", "/ignores-ctx"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assertEqual(t, http.StatusGatewayTimeout, w.Code)
+		if err := <-lateWrite; err != http.ErrHandlerTimeout {
+			t.Fatalf("%s: expecting the late write to fail with ErrHandlerTimeout, got %v", path, err)
+		}
+		assertEqual(t, "", w.Body.String())
+		assertEqual(t, "", w.Header().Get("X-Slow"))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/streaming", nil))
+	assertEqual(t, 200, w.Code)
+	assertEqual(t, "started and finished", w.Body.String())
+}
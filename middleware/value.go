@@ -6,6 +6,10 @@ import (
 )
 
 // WithValue is a middleware that sets a given key/value in a context chain.
+//
+// As with context.WithValue, the key should be of an unexported type defined
+// by your package, rather than a string or other built-in type, to avoid
+// collisions with keys set by other packages.
 func WithValue(key, val interface{}) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
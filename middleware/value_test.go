@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirMetathyst/go-penguin"
+)
+
+type valueTestKey struct{}
+
+func TestWithValue(t *testing.T) {
+	r := penguin.New()
+	r.With(WithValue(valueTestKey{}, "beta")).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Context().Value(valueTestKey{}).(string)))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assertEqual(t, "beta", w.Body.String())
+}
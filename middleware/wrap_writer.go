@@ -71,10 +71,17 @@ type basicWriter struct {
 	code        int
 	bytes       int
 	tee         io.Writer
+
+	// onWriteHeader, if set, is called just before the header is written to
+	// the proxied writer.
+	onWriteHeader func(code int)
 }
 
 func (b *basicWriter) WriteHeader(code int) {
 	if !b.wroteHeader {
+		if b.onWriteHeader != nil {
+			b.onWriteHeader(code)
+		}
 		b.code = code
 		b.wroteHeader = true
 		b.ResponseWriter.WriteHeader(code)
@@ -117,6 +124,12 @@ func (b *basicWriter) Unwrap() http.ResponseWriter {
 	return b.ResponseWriter
 }
 
+// basic returns the underlying basicWriter of any writer returned by
+// NewWrapResponseWriter.
+func (b *basicWriter) basic() *basicWriter {
+	return b
+}
+
 // flushWriter ...
 type flushWriter struct {
 	basicWriter
@@ -0,0 +1,78 @@
+package penguin
+
+import (
+	"context"
+	"mime/multipart"
+	"net/http"
+	"sync"
+)
+
+// MaxMultipartMemory is the default number of bytes of a multipart form held
+// in memory by ParseMultipart, the remainder of the file parts is stored in
+// temporary files on disk.
+var MaxMultipartMemory int64 = 32 << 20
+
+// ParseMultipart parses a multipart/form-data request body holding up to
+// MaxMultipartMemory bytes in memory. See ParseMultipartMemory.
+func ParseMultipart(r *http.Request) error {
+	return ParseMultipartMemory(r, MaxMultipartMemory)
+}
+
+// ParseMultipartMemory parses a multipart/form-data request body holding up to
+// `maxMemory` bytes of the file parts in memory, see
+// http.Request.ParseMultipartForm.
+//
+// When the request context was returned by WithMultipartCleanup, the parsed
+// form is recorded so its temporary files are removed once the request is
+// complete, even if the form was parsed on a copy of the request.
+func ParseMultipartMemory(r *http.Request, maxMemory int64) error {
+	parsed := r.MultipartForm != nil
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
+		return err
+	}
+	if !parsed {
+		if tracker, _ := r.Context().Value(multipartCleanupKey).(*multipartTracker); tracker != nil {
+			tracker.add(r.MultipartForm)
+		}
+	}
+	return nil
+}
+
+// CleanupMultipart removes the temporary files of the multipart form parsed on
+// the request, if any.
+func CleanupMultipart(r *http.Request) error {
+	if r.MultipartForm == nil {
+		return nil
+	}
+	return r.MultipartForm.RemoveAll()
+}
+
+// WithMultipartCleanup returns a copy of ctx that records the multipart forms
+// parsed by ParseMultipart, and a function removing their temporary files to
+// call once the request is complete. See middleware.CleanupMultipart.
+func WithMultipartCleanup(ctx context.Context) (context.Context, func()) {
+	tracker := &multipartTracker{}
+	return context.WithValue(ctx, multipartCleanupKey, tracker), tracker.removeAll
+}
+
+var multipartCleanupKey = &contextKey{"MultipartCleanup"}
+
+type multipartTracker struct {
+	mu    sync.Mutex
+	forms []*multipart.Form
+}
+
+func (t *multipartTracker) add(form *multipart.Form) {
+	t.mu.Lock()
+	t.forms = append(t.forms, form)
+	t.mu.Unlock()
+}
+
+func (t *multipartTracker) removeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, form := range t.forms {
+		_ = form.RemoveAll()
+	}
+	t.forms = nil
+}
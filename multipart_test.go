@@ -0,0 +1,95 @@
+package penguin
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func multipartRequest(t *testing.T, size int) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("name", "gopher")
+	fw, err := mw.CreateFormFile("upload", "big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(strings.Repeat("x", size)))
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+// uploadTempFile returns the name of the temporary file holding the upload, or
+// the empty string if it's held in memory.
+func uploadTempFile(t *testing.T, r *http.Request) string {
+	f, err := r.MultipartForm.File["upload"][0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if osf, ok := f.(*os.File); ok {
+		return osf.Name()
+	}
+	return ""
+}
+
+func TestParseMultipartMemory(t *testing.T) {
+	r := multipartRequest(t, 1024)
+	if err := ParseMultipartMemory(r, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	if r.FormValue("name") != "gopher" || uploadTempFile(t, r) != "" {
+		t.Fatal("expecting the upload to be held in memory")
+	}
+
+	r = multipartRequest(t, 1024)
+	if err := ParseMultipartMemory(r, 16); err != nil {
+		t.Fatal(err)
+	}
+	name := uploadTempFile(t, r)
+	if name == "" {
+		t.Fatal("expecting the upload to be stored in a temporary file")
+	}
+	if err := CleanupMultipart(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Fatalf("expecting the temporary file to be removed, got %v", err)
+	}
+}
+
+func TestWithMultipartCleanup(t *testing.T) {
+	ctx, cleanup := WithMultipartCleanup(multipartRequest(t, 0).Context())
+
+	// parse the form on a copy of the request, as a handler behind a
+	// middleware calling r.WithContext would
+	r := multipartRequest(t, 1024).WithContext(ctx)
+	if err := ParseMultipartMemory(r, 16); err != nil {
+		t.Fatal(err)
+	}
+	name := uploadTempFile(t, r)
+
+	cleanup()
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Fatalf("expecting the temporary file to be removed, got %v", err)
+	}
+}
+
+func TestBindFormMultipart(t *testing.T) {
+	var v struct {
+		Name string `form:"name"`
+	}
+	if err := BindForm(multipartRequest(t, 10), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "gopher" {
+		t.Fatalf("unexpected binding %+v", v)
+	}
+}
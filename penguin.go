@@ -42,6 +42,11 @@
 // matched. An anonymous regexp pattern is allowed, using an empty string
 // before the colon in the placeholder, such as {:\\d+}
 //
+// A placeholder ending the pattern may be made optional with a question mark
+// after its name, such as {name?} or {id?:\\d+}, in which case the pattern
+// also matches the path without the final segment and the param is empty.
+// "/files/{name?}" matches both "/files/report.pdf" and "/files".
+//
 // The special placeholder of asterisk matches the rest of the requested
 // URL. Any trailing characters in the pattern are ignored. This is the only
 // placeholder which will match / characters.
@@ -56,8 +61,12 @@
 package penguin
 
 import (
+	"context"
+	"html/template"
+	"io"
 	"io/fs"
 	"net/http"
+	"time"
 )
 
 // Controller represents a portion of functionality for a web application whereby middleware
@@ -66,6 +75,39 @@ type Controller interface {
 	Router(r Router)
 }
 
+// ControllerMiddleware is implemented by a Controller or ResourceController
+// whose middlewares apply to all of its routes. Router.Controller and
+// Router.Resource call Use with them on the controller's sub-router before
+// registering its routes.
+type ControllerMiddleware interface {
+	Middlewares() []func(http.Handler) http.Handler
+}
+
+// Initializer is implemented by a Controller or ResourceController that needs
+// setting up, such as loading configuration, once before its routes are
+// registered. If Init fails the controller isn't registered.
+type Initializer interface {
+	Init() error
+}
+
+// ResourceController is a controller for a RESTful resource, mounted with
+// Router.Resource. It may implement any of the following actions, each with
+// the signature of a http.HandlerFunc, and only the routes of the actions it
+// implements are registered:
+//
+//	Index   GET    /
+//	New     GET    /new
+//	Create  POST   /
+//	Show    GET    /{id}
+//	Edit    GET    /{id}/edit
+//	Update  PUT    /{id} and PATCH /{id}
+//	Delete  DELETE /{id}
+//
+// If it also implements ControllerMiddleware its middlewares are used, and if
+// it implements Controller its Router method is called, before the action
+// routes are registered. Router.Resource panics if it implements neither.
+type ResourceController interface{}
+
 // Router consisting of the core routing methods used by chi's Engine,
 // using only the standard net/http.
 type Router interface {
@@ -98,16 +140,17 @@ type Router interface {
 	Method(method, pattern string, h http.Handler)
 	MethodFunc(method, pattern string, h http.HandlerFunc)
 
-	// HTTP-method routing along `pattern`
-	Connect(pattern string, h http.HandlerFunc)
-	Delete(pattern string, h http.HandlerFunc)
-	Get(pattern string, h http.HandlerFunc)
-	Head(pattern string, h http.HandlerFunc)
-	Options(pattern string, h http.HandlerFunc)
-	Patch(pattern string, h http.HandlerFunc)
-	Post(pattern string, h http.HandlerFunc)
-	Put(pattern string, h http.HandlerFunc)
-	Trace(pattern string, h http.HandlerFunc)
+	// HTTP-method routing along `pattern`, the optional middlewares wrap
+	// just the route's handler, after the Use stack of the Router.
+	Connect(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Delete(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Get(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Head(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Options(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Patch(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Post(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Put(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
+	Trace(pattern string, h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler)
 
 	// NotFound defines a handler to respond whenever a route could
 	// not be found.
@@ -119,13 +162,84 @@ type Router interface {
 
 	// PENGUIN EXTRA'S
 
+	// RemoveRoute deletes the route registered for `method` on the exact
+	// routing `pattern`, leaving any other methods on the pattern intact.
+	RemoveRoute(method, pattern string) error
+
+	// HasRoute reports whether a route is registered for `method` on the
+	// exact routing `pattern`.
+	HasRoute(method, pattern string) bool
+
+	// PrintRoutes writes a table of the routes of the Router to `w`.
+	PrintRoutes(w io.Writer)
+
+	// RoutesHandler returns a handler responding with the routes of the
+	// Router as JSON.
+	RoutesHandler() http.HandlerFunc
+
+	// SubRouters returns the sub-routers mounted on the Router keyed by
+	// their mount pattern.
+	SubRouters() map[string]Routes
+
+	// AutoOptions enables or disables automatic responses to OPTIONS requests
+	// listing the methods allowed on routes without an OPTIONS handler.
+	AutoOptions(enabled bool)
+
+	// AutoHead enables or disables serving HEAD requests with the GET handler
+	// of routes without a HEAD handler.
+	AutoHead(enabled bool)
+
+	// NotFoundTemplate sets a 404 handler rendering the template `name`
+	// through the template engine of the request context.
+	NotFoundTemplate(name string, v any)
+
+	// MethodNotAllowedTemplate sets a 405 handler rendering the template
+	// `name` through the template engine of the request context.
+	MethodNotAllowedTemplate(name string, v any)
+
+	// WithTimeout is like With but the routes are served with a request
+	// context that is cancelled after `d`.
+	WithTimeout(d time.Duration) Router
+
+	// Handle404Fallthrough sets a handler serving every unmatched request
+	// with its original path, such as a reverse proxy.
+	Handle404Fallthrough(h http.Handler)
+
+	// RedirectTrailingSlash enables or disables redirecting requests that only
+	// match a route with the trailing slash added or removed.
+	RedirectTrailingSlash(enabled bool)
+
+	// Name assigns a name to a routing pattern of the Router so its URL can
+	// be built with URL.
+	Name(name, pattern string)
+
+	// URL returns the path of a named route with its parameters replaced,
+	// searching the mounted sub-routers too.
+	URL(name string, params map[string]string) (string, error)
+
+	// MatchPattern is like Match but also returns the full routing pattern of
+	// the matched route, including the patterns of mounted sub-routers.
+	MatchPattern(rctx *Context, method, path string) (string, bool)
+
 	// Controller is a shorthand for Router.Route("/pattern", MyController{}.Router)
 	// and makes it clearer that a controller is being used at a glance.
 	Controller(pattern string, c Controller)
 
+	// ControllerE is like Controller but returns the error of the controller's
+	// Initializer instead of panicking.
+	ControllerE(pattern string, c Controller) error
+
+	// Resource mounts the conventional RESTful routes of the actions
+	// implemented by `c` along the `pattern`. See ResourceController.
+	Resource(pattern string, c ResourceController)
+
 	// HTML takes an ExecuteTemplate interface to handle execution of templates.
 	HTML(handler ExecuteTemplate)
 
+	// HTMLEngine injects any template engine satisfying ExecuteTemplate for
+	// use by the render helpers.
+	HTMLEngine(engine ExecuteTemplate)
+
 	// HTMLGlob parses the template definitions in the files identified by the patterns and calls Engine.Use
 	// with middleware that injects the templates for use by HTML. If the templates fail to parse the method will panic.
 	HTMLGlob(pattern ...string)
@@ -135,23 +249,88 @@ type Router interface {
 	// request if reload is set to true. If the templates fail to parse the method will panic.
 	HTMLGlobReloadable(reload bool, pattern ...string)
 
+	// HTMLGlobWatch is like HTMLGlob but reparses the templates whenever a
+	// matching file changes.
+	HTMLGlobWatch(patterns ...string)
+
+	// HTMLGlobWatchContext is like HTMLGlobWatch but stops watching the
+	// templates once ctx is done.
+	HTMLGlobWatchContext(ctx context.Context, patterns ...string)
+
 	// HTMLFs is like Engine.HTML or Engine.HTMLGlob but reads from the file system fs instead of the host operating system's file system.
 	// It accepts a list of glob patterns (Note that most file names serve as glob patterns matching only themselves.) and
 	// will be injected into each request for use by HTML. If the templates fail to parse the method will panic.
 	HTMLFs(fs fs.FS, patterns ...string)
 
+	// HTMLGlobFuncs is like HTMLGlob but adds funcs to the templates before
+	// parsing them.
+	HTMLGlobFuncs(funcs template.FuncMap, patterns ...string)
+
+	// HTMLFsFuncs is like HTMLFs but adds funcs to the templates before
+	// parsing them.
+	HTMLFsFuncs(funcs template.FuncMap, fs fs.FS, patterns ...string)
+
+	// HTMLGlobDelims is like HTMLGlob but parses the templates with the
+	// action delimiters left and right.
+	HTMLGlobDelims(left, right string, patterns ...string)
+
+	// HTMLFsDelims is like HTMLFs but parses the templates with the action
+	// delimiters left and right.
+	HTMLFsDelims(left, right string, fs fs.FS, patterns ...string)
+
+	// HTMLGlobE is like HTMLGlob but returns the parse error instead of
+	// panicking.
+	HTMLGlobE(patterns ...string) error
+
+	// HTMLFsE is like HTMLFs but returns the parse error instead of panicking.
+	HTMLFsE(fs fs.FS, patterns ...string) error
+
 	// HTMLFsReloadable is like Engine.HTML or Engine.HTMLGlob but reads from the file system fs instead of the host operating system's file system.
 	// It accepts a list of glob patterns (Note that most file names serve as glob patterns matching only themselves.) and
 	// will be injected into each request for use by HTML. The templates will be reloaded and parsed on each
 	// request when reload is set to true. If the templates fail to parse the method will panic.
 	HTMLFsReloadable(reload bool, fs fs.FS, patterns ...string)
 
+	// Text takes an ExecuteTemplate interface, such as a text/template, to
+	// handle execution of the non-escaping templates used by TextTemplate.
+	Text(handler ExecuteTemplate)
+
+	// TextGlob parses the files identified by the patterns with text/template
+	// for use by TextTemplate.
+	TextGlob(patterns ...string)
+
+	// TextFs is like TextGlob but reads from the file system fs.
+	TextFs(fs fs.FS, patterns ...string)
+
 	// Static adds a handler using http.FileSystem that serves HTTP requests with the contents of the file system rooted at rootPath.
 	Static(rootPath string)
 
+	// MountSPA mounts a single-page application served from fsys along `pattern`,
+	// falling back to its index.html for paths that aren't files in fsys.
+	MountSPA(pattern string, fsys fs.FS)
+
+	// StaticSPA serves a single-page application from rootPath under
+	// urlPrefix, falling back to indexFile for client-side routes.
+	StaticSPA(urlPrefix, rootPath, indexFile string)
+
+	// StaticSPAFS is like StaticSPA but serves the application from fsys.
+	StaticSPAFS(urlPrefix string, fsys fs.FS, indexFile string)
+
 	// StaticFS adds a handler using http.FileSystem that serves HTTP requests with the contents of the file system rooted at rootPath.
 	// fs is converted to a FileSystem implementation, for use with the FileServer.
 	StaticFS(fs fs.FS)
+
+	// StaticAt is like Static but serves the files under urlPrefix.
+	StaticAt(urlPrefix, rootPath string)
+
+	// StaticFSAt is like StaticFS but serves the files under urlPrefix.
+	StaticFSAt(urlPrefix string, fs fs.FS)
+
+	// StaticFile registers a GET handler on pattern serving a single file.
+	StaticFile(pattern, filePath string)
+
+	// StaticFileFS is like StaticFile but serves the file name from fsys.
+	StaticFileFS(pattern string, fsys fs.FS, name string)
 }
 
 // Routes interface adds two methods for router traversal, which is also
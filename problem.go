@@ -0,0 +1,74 @@
+package penguin
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem is a RFC 7807 problem details object, describing an error in a
+// machine-readable format.
+type Problem struct {
+	// Type is a URI reference identifying the problem type, which defaults to
+	// "about:blank" when omitted.
+	Type string `json:"type,omitempty"`
+
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+
+	// Status is the HTTP status code of the response.
+	Status int `json:"status,omitempty"`
+
+	// Detail is a human-readable explanation of this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Extensions are additional members written alongside the standard ones
+	// at the top-level of the object. They never replace a standard member.
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler, flattening the extension members into
+// the problem object.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	b, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+
+	members := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	var standard map[string]any
+	if err := json.Unmarshal(b, &standard); err != nil {
+		return nil, err
+	}
+	for k, v := range standard {
+		members[k] = v
+	}
+	return json.Marshal(members)
+}
+
+// ProblemJSON marshals the problem 'p' to JSON, setting the Content-Type as
+// application/problem+json and writing p.Status as the status code. The status
+// defaults to 500 Internal Server Error and the title to the status text.
+func ProblemJSON(w http.ResponseWriter, r *http.Request, p Problem) error {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(b)
+	return nil
+}
@@ -0,0 +1,66 @@
+package penguin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := ProblemJSON(w, httptest.NewRequest("GET", "/", nil), Problem{
+		Type:     "https://example.com/probs/out-of-credit",
+		Status:   http.StatusForbidden,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+		Extensions: map[string]any{
+			"balance": 30,
+			"status":  "ignored",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expecting status 403, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"type":     "https://example.com/probs/out-of-credit",
+		"title":    "Forbidden",
+		"status":   float64(403),
+		"detail":   "Your current balance is 30, but that costs 50.",
+		"instance": "/account/12345/msgs/abc",
+		"balance":  float64(30),
+	}
+	if len(body) != len(expected) {
+		t.Fatalf("unexpected members %v", body)
+	}
+	for k, v := range expected {
+		if body[k] != v {
+			t.Errorf("%s = %v, expecting %v", k, body[k], v)
+		}
+	}
+}
+
+func TestProblemJSONDefaults(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := ProblemJSON(w, httptest.NewRequest("GET", "/", nil), Problem{}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expecting status 500, got %d", w.Code)
+	}
+	if body := w.Body.String(); body != `{"title":"Internal Server Error","status":500}` {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
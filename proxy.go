@@ -0,0 +1,80 @@
+package penguin
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// Proxy returns a reverse proxy forwarding requests to the `target` backend.
+// Only the path left to route is forwarded, so a backend mounted along a
+// prefix receives the tail of the path, for example:
+//
+//	backend, _ := url.Parse("http://legacy.internal:8080/v1")
+//	r.Mount("/api/legacy", penguin.Proxy(backend))
+//
+// forwards `/api/legacy/users/1` to `http://legacy.internal:8080/v1/users/1`.
+// Routes registered with a trailing wildcard, such as `/api/legacy/*`, forward
+// the wildcard match in the same way.
+//
+// The X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto headers are set
+// from the incoming request, and X-Forwarded-Prefix carries the stripped
+// prefix so the backend can build links back through the proxy.
+func Proxy(target *url.URL) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+
+	proxy.Director = func(req *http.Request) {
+		host, proto := req.Host, "http"
+		if req.TLS != nil {
+			proto = "https"
+		}
+
+		if tail, ok := proxyPath(req); ok {
+			// The tail is escaped when the request was routed by its RawPath.
+			escaped := tail
+			if req.URL.RawPath == "" {
+				escaped = (&url.URL{Path: tail}).EscapedPath()
+			}
+			prefix := strings.TrimSuffix(strings.TrimSuffix(req.URL.EscapedPath(), escaped), "/")
+			if req.URL.RawPath != "" {
+				if path, err := url.PathUnescape(escaped); err == nil {
+					req.URL.Path, req.URL.RawPath = path, escaped
+				}
+			} else {
+				req.URL.Path = tail
+			}
+			if prefix != "" {
+				req.Header.Set("X-Forwarded-Prefix", prefix)
+			}
+		}
+		director(req)
+
+		req.Header.Set("X-Forwarded-Host", host)
+		req.Header.Set("X-Forwarded-Proto", proto)
+		req.Host = target.Host
+	}
+	return proxy
+}
+
+// proxyPath returns the path left to route for the request, from the wildcard
+// of the matched route, even within a sub-router, or else the routing path left
+// by Mount, which clears the wildcard it matched.
+func proxyPath(r *http.Request) (string, bool) {
+	rctx := RouteContext(r.Context())
+	if rctx == nil {
+		return "", false
+	}
+	wildcard := strings.HasSuffix(rctx.RoutePattern(), "/*")
+	if tail := rctx.URLParam("*"); wildcard && tail != "" {
+		return "/" + tail, true
+	}
+	if rctx.RoutePath != "" {
+		return rctx.RoutePath, true
+	}
+	if wildcard {
+		return "/", true
+	}
+	return "", false
+}
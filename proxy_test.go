@@ -0,0 +1,46 @@
+package penguin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s?%s prefix=%s host=%s proto=%s for=%t",
+			r.Method, r.URL.EscapedPath(), r.URL.RawQuery,
+			r.Header.Get("X-Forwarded-Prefix"), r.Header.Get("X-Forwarded-Host"),
+			r.Header.Get("X-Forwarded-Proto"), r.Header.Get("X-Forwarded-For") != "")
+	}))
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL + "/v1")
+
+	r := New()
+	r.Mount("/api/legacy", Proxy(target))
+	r.Handle("/files/*", Proxy(target))
+	r.Route("/api", func(r Router) {
+		r.Handle("/old/*", Proxy(target))
+	})
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	host := ts.Listener.Addr().String()
+
+	tests := map[string]string{
+		"/api/legacy/users/1?x=1": "GET /v1/users/1?x=1 prefix=/api/legacy host=" + host + " proto=http for=true",
+		"/api/legacy":             "GET /v1/? prefix=/api/legacy host=" + host + " proto=http for=true",
+		"/files/a/b.txt":          "GET /v1/a/b.txt? prefix=/files host=" + host + " proto=http for=true",
+		"/api/legacy/a%2Fb/c":     "GET /v1/a%2Fb/c? prefix=/api/legacy host=" + host + " proto=http for=true",
+		"/files/a%2Fb/c.txt":      "GET /v1/a%2Fb/c.txt? prefix=/files host=" + host + " proto=http for=true",
+		"/api/old/users/1":        "GET /v1/users/1? prefix=/api/old host=" + host + " proto=http for=true",
+	}
+	for path, expected := range tests {
+		if _, body := testRequest(t, ts, "GET", path, nil); body != expected {
+			t.Errorf("%s: got %q, expecting %q", path, body, expected)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Package yaml renders YAML responses, mirroring the penguin.JSON and
+// penguin.XML helpers. It lives in its own package so only applications that
+// serve YAML depend on gopkg.in/yaml.v3.
+package yaml
+
+import (
+	"bytes"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML marshals 'v' to YAML, setting the Content-Type as application/yaml.
+func YAML(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+	return nil
+}
@@ -0,0 +1,44 @@
+package yaml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type config struct {
+	Name    string   `yaml:"name"`
+	Debug   bool     `yaml:"debug,omitempty"`
+	Servers []string `yaml:"servers"`
+}
+
+func TestYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        any
+		expected string
+	}{
+		{
+			"nested map",
+			map[string]any{"database": map[string]any{"host": "localhost", "port": 5432}},
+			"database:\n  host: localhost\n  port: 5432\n",
+		},
+		{
+			"struct",
+			config{Name: "penguin", Servers: []string{"a", "b"}},
+			"name: penguin\nservers:\n  - a\n  - b\n",
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := YAML(w, httptest.NewRequest("GET", "/", nil), http.StatusOK, tt.v); err != nil {
+			t.Fatal(err)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/yaml; charset=utf-8" {
+			t.Errorf("%s: unexpected Content-Type %q", tt.name, ct)
+		}
+		if body := w.Body.String(); body != tt.expected {
+			t.Errorf("%s: unexpected body %q", tt.name, body)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package penguin
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var trueClientIP = http.CanonicalHeaderKey("True-Client-IP")
+var xForwardedFor = http.CanonicalHeaderKey("X-Forwarded-For")
+var xRealIP = http.CanonicalHeaderKey("X-Real-IP")
+
+// ClientIP returns the IP address of the client that made the request. It
+// honours the True-Client-IP, X-Real-IP and X-Forwarded-For headers (in that
+// order) set by reverse proxies, and falls back to the host of r.RemoteAddr.
+//
+// Only rely on the proxy headers if they are set by a proxy you trust,
+// otherwise clients are free to spoof them.
+func ClientIP(r *http.Request) string {
+	var ip string
+	if tcip := r.Header.Get(trueClientIP); tcip != "" {
+		ip = tcip
+	} else if xrip := r.Header.Get(xRealIP); xrip != "" {
+		ip = xrip
+	} else if xff := r.Header.Get(xForwardedFor); xff != "" {
+		i := strings.Index(xff, ",")
+		if i == -1 {
+			i = len(xff)
+		}
+		ip = xff[:i]
+	}
+	if ip = strings.TrimSpace(ip); ip != "" && net.ParseIP(ip) != nil {
+		return ip
+	}
+	return remoteAddrHost(r)
+}
+
+// remoteAddrHost returns the host of r.RemoteAddr, or r.RemoteAddr itself if it
+// has no port.
+func remoteAddrHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// IsHTTPS reports whether the request reached the server, or the reverse proxy
+// in front of it, over TLS, according to the connection state and the
+// X-Forwarded-Proto and Forwarded headers set by proxies.
+//
+// Only rely on the proxy headers if they are set by a proxy you trust,
+// otherwise clients are free to spoof them.
+func IsHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return strings.EqualFold(strings.TrimSpace(strings.Split(proto, ",")[0]), "https")
+	}
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		first, _, _ := strings.Cut(fwd, ",")
+		for _, pair := range strings.Split(first, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if strings.EqualFold(k, "proto") {
+				return strings.EqualFold(strings.Trim(v, `"`), "https")
+			}
+		}
+	}
+	return false
+}
+
+// IsAjax reports whether the request was made with XMLHttpRequest, as
+// signalled by the X-Requested-With header.
+func IsAjax(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("X-Requested-With"), "XMLHttpRequest")
+}
+
+// IsJSON reports whether the request accepts a JSON response, or carries a
+// JSON body, according to its Accept and Content-Type headers.
+func IsJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if isJSONMediaType(accept) {
+			return true
+		}
+	}
+	return isJSONMediaType(r.Header.Get("Content-Type"))
+}
+
+// isJSONMediaType reports whether v is application/json or a +json suffixed
+// media type, ignoring any parameters.
+func isJSONMediaType(v string) bool {
+	mt, _, err := mime.ParseMediaType(strings.TrimSpace(v))
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// Errors returned by BearerToken.
+var (
+	ErrNoAuthorization = errors.New("penguin: missing Authorization header")
+	ErrNotBearer       = errors.New("penguin: Authorization header is not a Bearer scheme")
+	ErrEmptyBearer     = errors.New("penguin: empty Bearer token")
+)
+
+// BearerToken returns the token of a `Bearer` scheme Authorization header. The
+// scheme is matched case-insensitively and any whitespace around the token is
+// trimmed. It returns ErrNoAuthorization, ErrNotBearer or ErrEmptyBearer when
+// the header is missing, uses another scheme or carries no token.
+func BearerToken(r *http.Request) (string, error) {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if auth == "" {
+		return "", ErrNoAuthorization
+	}
+	scheme, token := auth, ""
+	if i := strings.IndexAny(auth, " \t"); i != -1 {
+		scheme, token = auth[:i], auth[i+1:]
+	}
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrNotBearer
+	}
+	if token = strings.TrimSpace(token); token == "" {
+		return "", ErrEmptyBearer
+	}
+	return token, nil
+}
+
+// Bearer is like BearerToken but returns the empty string if the request
+// doesn't carry a Bearer token.
+func Bearer(r *http.Request) string {
+	token, _ := BearerToken(r)
+	return token
+}
+
+// ErrMissingQueryParam is wrapped by the error of QueryIntStrict when the
+// request has no value for the query parameter.
+var ErrMissingQueryParam = errors.New("penguin: missing query param")
+
+// QueryString returns the first value of the query parameter `key` of the
+// request URL, or `def` if it's absent or empty.
+func QueryString(r *http.Request, key, def string) string {
+	if v := r.URL.Query().Get(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// QueryInt returns the query parameter `key` of the request URL parsed as an
+// int, or `def` if it's absent or malformed. See QueryIntStrict.
+func QueryInt(r *http.Request, key string, def int) int {
+	n, err := QueryIntStrict(r, key)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// QueryIntStrict returns the query parameter `key` of the request URL parsed
+// as an int. The returned error names the parameter and wraps
+// ErrMissingQueryParam or the strconv parse error.
+func QueryIntStrict(r *http.Request, key string) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return 0, fmt.Errorf("%w '%s'", ErrMissingQueryParam, key)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("penguin: invalid query param '%s': %w", key, err)
+	}
+	return n, nil
+}
+
+// QueryBool returns the query parameter `key` of the request URL parsed with
+// strconv.ParseBool, or `def` if it's absent or malformed.
+func QueryBool(r *http.Request, key string, def bool) bool {
+	b, err := strconv.ParseBool(r.URL.Query().Get(key))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// RequestSummary returns the key attributes of a request as a map suitable for
+// passing to a structured logger. The matched route pattern and request ID are
+// read from the request context when they are present.
+//
+// The remote_ip is the host of r.RemoteAddr, which unlike ClientIP can't be
+// spoofed with proxy headers. Behind a reverse proxy, use a middleware such as
+// middleware.RealIPFromTrusted to set r.RemoteAddr to the client address first.
+func RequestSummary(r *http.Request) map[string]any {
+	var pattern string
+	if rctx := RouteContext(r.Context()); rctx != nil {
+		pattern = rctx.RoutePattern()
+	}
+
+	return map[string]any{
+		"method":         r.Method,
+		"pattern":        pattern,
+		"path":           r.URL.Path,
+		"query":          r.URL.RawQuery,
+		"remote_ip":      remoteAddrHost(r),
+		"request_id":     RequestIDFromCtx(r.Context()),
+		"user_agent":     r.UserAgent(),
+		"content_length": r.ContentLength,
+	}
+}
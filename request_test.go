@@ -0,0 +1,209 @@
+package penguin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRequestSummary(t *testing.T) {
+	var summary map[string]any
+
+	r := New()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), RequestIDKey, "req-1")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Post("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		summary = RequestSummary(r)
+	})
+
+	req := httptest.NewRequest("POST", "/users/42?expand=1", strings.NewReader("body"))
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("User-Agent", "penguin-test")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := map[string]any{
+		"method":         "POST",
+		"pattern":        "/users/{id}",
+		"path":           "/users/42",
+		"query":          "expand=1",
+		"remote_ip":      "10.0.0.1",
+		"request_id":     "req-1",
+		"user_agent":     "penguin-test",
+		"content_length": int64(4),
+	}
+	for k, v := range expected {
+		if summary[k] != v {
+			t.Errorf("summary[%q] = %v, expecting %v", k, summary[k], v)
+		}
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?page=3&limit=ten&debug=true&quiet=maybe&name=gopher&empty=", nil)
+
+	if v := QueryString(r, "name", "anon"); v != "gopher" {
+		t.Errorf("QueryString(name) = %q", v)
+	}
+	if v := QueryString(r, "empty", "anon"); v != "anon" {
+		t.Errorf("QueryString(empty) = %q", v)
+	}
+	if v := QueryString(r, "missing", "anon"); v != "anon" {
+		t.Errorf("QueryString(missing) = %q", v)
+	}
+
+	if v := QueryInt(r, "page", 1); v != 3 {
+		t.Errorf("QueryInt(page) = %d", v)
+	}
+	if v := QueryInt(r, "limit", 20); v != 20 {
+		t.Errorf("QueryInt(limit) = %d", v)
+	}
+	if v := QueryInt(r, "missing", 20); v != 20 {
+		t.Errorf("QueryInt(missing) = %d", v)
+	}
+
+	if v := QueryBool(r, "debug", false); !v {
+		t.Errorf("QueryBool(debug) = %v", v)
+	}
+	if v := QueryBool(r, "quiet", true); !v {
+		t.Errorf("QueryBool(quiet) = %v", v)
+	}
+	if v := QueryBool(r, "missing", false); v {
+		t.Errorf("QueryBool(missing) = %v", v)
+	}
+
+	if n, err := QueryIntStrict(r, "page"); n != 3 || err != nil {
+		t.Errorf("QueryIntStrict(page) = %d, %v", n, err)
+	}
+	if _, err := QueryIntStrict(r, "missing"); !errors.Is(err, ErrMissingQueryParam) {
+		t.Errorf("expecting ErrMissingQueryParam, got %v", err)
+	}
+	_, err := QueryIntStrict(r, "limit")
+	if !errors.Is(err, strconv.ErrSyntax) || err.Error() != `penguin: invalid query param 'limit': strconv.Atoi: parsing "ten": invalid syntax` {
+		t.Errorf("unexpected error for a malformed value: %v", err)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected string
+	}{
+		{"remote addr", http.Header{}, "10.0.0.1"},
+		{"true client ip", http.Header{"True-Client-Ip": {"100.100.100.100"}}, "100.100.100.100"},
+		{"real ip", http.Header{"X-Real-Ip": {"100.100.100.101"}}, "100.100.100.101"},
+		{"forwarded for chain", http.Header{"X-Forwarded-For": {"100.100.100.102, 10.0.0.2"}}, "100.100.100.102"},
+		{"invalid header", http.Header{"X-Real-Ip": {"not-an-ip"}}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header = tt.header
+		if ip := ClientIP(req); ip != tt.expected {
+			t.Errorf("%s: ClientIP = %q, expecting %q", tt.name, ip, tt.expected)
+		}
+	}
+}
+
+func TestIsAjax(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if IsAjax(req) {
+		t.Fatal("plain request should not be ajax")
+	}
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	if !IsAjax(req) {
+		t.Fatal("expecting an ajax request")
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	tests := []struct {
+		accept, contentType string
+		expected            bool
+	}{
+		{"", "", false},
+		{"text/html, application/json;q=0.9", "", true},
+		{"application/problem+json", "", true},
+		{"", "application/json; charset=utf-8", true},
+		{"text/html", "application/x-www-form-urlencoded", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		req.Header.Set("Content-Type", tt.contentType)
+		if IsJSON(req) != tt.expected {
+			t.Errorf("IsJSON(Accept: %q, Content-Type: %q) != %v", tt.accept, tt.contentType, tt.expected)
+		}
+	}
+}
+
+func TestBearer(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"Basic dXNlcjpwYXNz": "",
+		"Bearer abc.def":     "abc.def",
+		"bearer   abc.def ":  "abc.def",
+		"Bearer":             "",
+	}
+	for header, expected := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", header)
+		if token := Bearer(req); token != expected {
+			t.Errorf("Bearer(%q) = %q, expecting %q", header, token, expected)
+		}
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+		err      error
+	}{
+		{"", "", ErrNoAuthorization},
+		{"Basic dXNlcjpwYXNz", "", ErrNotBearer},
+		{"Bearerabc", "", ErrNotBearer},
+		{"Bearer ", "", ErrEmptyBearer},
+		{"Bearer\t", "", ErrEmptyBearer},
+		{"Bearer\tabc", "abc", nil},
+		{"BEARER    abc.def  ", "abc.def", nil},
+		{"Bearer abc.def", "abc.def", nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", tt.header)
+		token, err := BearerToken(req)
+		if token != tt.expected || err != tt.err {
+			t.Errorf("BearerToken(%q) = %q, %v, expecting %q, %v", tt.header, token, err, tt.expected, tt.err)
+		}
+	}
+}
+
+func TestIsHTTPS(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		expected bool
+	}{
+		{http.Header{}, false},
+		{http.Header{"X-Forwarded-Proto": {"https"}}, true},
+		{http.Header{"X-Forwarded-Proto": {"http"}}, false},
+		{http.Header{"Forwarded": {`for=192.0.2.60;proto=https;by=203.0.113.43`}}, true},
+		{http.Header{"Forwarded": {`proto=http, proto=https`}}, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header = tt.header
+		if IsHTTPS(r) != tt.expected {
+			t.Errorf("IsHTTPS(%v) != %v", tt.header, tt.expected)
+		}
+	}
+}
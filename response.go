@@ -2,36 +2,286 @@ package penguin
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
 	"io"
+	"mime"
 	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"unicode/utf8"
 )
 
 type M map[string]any
 
 type S []any
 
+// ExecuteTemplate is the template engine the render helpers, such as HTML and
+// TextTemplate, execute templates with. Both html/template and text/template
+// satisfy it, and any other engine can be plugged in with Engine.HTMLEngine by
+// wrapping it in a type with this method:
+//
+//	type mustacheEngine struct {
+//		templates map[string]*mustache.Template
+//	}
+//
+//	func (e mustacheEngine) ExecuteTemplate(w io.Writer, name string, data any) error {
+//		t, ok := e.templates[name]
+//		if !ok {
+//			return fmt.Errorf("no template %q", name)
+//		}
+//		return t.FRender(w, data)
+//	}
+//
+// The engine must be safe for concurrent use, as it's shared by all requests.
 type ExecuteTemplate interface {
 	ExecuteTemplate(w io.Writer, name string, data any) error
 }
 
-// HTML writes a string to the response, setting the Content-Type as text/template.
+// ErrNoRenderer is returned by HTMLErr when no template engine is assigned to
+// the request context.
+var ErrNoRenderer = errors.New("penguin: template renderer not assigned")
+
+// HTML executes the template `name` with the engine assigned to the request
+// context and writes the result to the response, setting the Content-Type as
+// text/html. When `v` is an M, or nil, it's merged with the base template data
+// of the request context, see WithTemplateData. It panics if no engine is
+// assigned, see HTMLErr.
 func HTML(w http.ResponseWriter, r *http.Request, status int, name string, v any) error {
-	if renderer := HTMLEngineFromCtx(r.Context()); renderer != nil {
-		var buf bytes.Buffer
-		if err := renderer.ExecuteTemplate(&buf, name, v); err != nil {
+	err := HTMLErr(w, r, status, name, v)
+	if err == ErrNoRenderer {
+		panic(err.Error())
+	}
+	return err
+}
+
+// HTMLErr is like HTML but returns ErrNoRenderer instead of panicking when no
+// engine is assigned to the request context. Nothing is written to the
+// response when an error is returned.
+func HTMLErr(w http.ResponseWriter, r *http.Request, status int, name string, v any) error {
+	renderer := HTMLEngineFromCtx(r.Context())
+	if renderer == nil {
+		return ErrNoRenderer
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.ExecuteTemplate(&buf, name, templateData(r, v)); err != nil {
+		return err
+	}
+	if rec, _ := r.Context().Value(templateRecorderKey).(*templateRecorder); rec != nil {
+		rec.record(name)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+	return nil
+}
+
+// templateData merges the base template data of the request context, see
+// WithTemplateData, with v when v is an M, the keys of v winning. Any other v
+// is returned as is.
+func templateData(r *http.Request, v any) any {
+	base := TemplateDataFromCtx(r.Context())
+	m, ok := v.(M)
+	if base == nil || (!ok && v != nil) {
+		return v
+	}
+	merged := make(M, len(base)+len(m))
+	for k, val := range base {
+		merged[k] = val
+	}
+	for k, val := range m {
+		merged[k] = val
+	}
+	return merged
+}
+
+// HTMLStream is like HTML but writes the header and status first and then
+// executes the template `name` directly to the response, rather than buffering
+// the whole page in memory. It suits large pages, at the cost of error
+// handling: once the status is sent an execution error can't change it, and
+// the client receives the page up to the point of failure. The error is
+// returned for logging only.
+func HTMLStream(w http.ResponseWriter, r *http.Request, status int, name string, v any) error {
+	renderer := HTMLEngineFromCtx(r.Context())
+	if renderer == nil {
+		panic(ErrNoRenderer.Error())
+	}
+
+	if rec, _ := r.Context().Value(templateRecorderKey).(*templateRecorder); rec != nil {
+		rec.record(name)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	return renderer.ExecuteTemplate(w, name, templateData(r, v))
+}
+
+// HTMLLayout is like HTML but executes the template `layout` with the block
+// named LayoutBlock, "body" by default, filled by the template `content`, so
+// pages can share a base layout:
+//
+//	// base.tmpl
+//	<html><body>{{block "body" .}}no content{{end}}</body></html>
+//
+//	// page.tmpl
+//	<h1>{{.title}}</h1>
+//
+//	penguin.HTMLLayout(w, r, 200, "base.tmpl", "page.tmpl", penguin.M{"title": "Hello"})
+//
+// The engines of Engine.HTMLGlob and Engine.HTMLFs support layouts, and so does
+// a *html/template.Template assigned with Engine.HTML as long as it has not
+// been executed yet.
+func HTMLLayout(w http.ResponseWriter, r *http.Request, status int, layout, content string, v any) error {
+	renderer := HTMLEngineFromCtx(r.Context())
+	if renderer == nil {
+		panic(ErrNoRenderer.Error())
+	}
+
+	v = templateData(r, v)
+	var buf bytes.Buffer
+	switch t := renderer.(type) {
+	case layoutExecutor:
+		if err := t.ExecuteLayout(&buf, layout, content, v); err != nil {
+			return err
+		}
+	case *htmltemplate.Template:
+		tmpl, err := withLayoutBlock(t, content)
+		if err != nil {
 			return err
 		}
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(status)
-		_, _ = buf.WriteTo(w)
+		if err := tmpl.ExecuteTemplate(&buf, layout, v); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("penguin: template renderer %T doesn't support layouts", renderer)
+	}
+	if rec, _ := r.Context().Value(templateRecorderKey).(*templateRecorder); rec != nil {
+		rec.record(layout)
+		rec.record(content)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+	return nil
+}
+
+// TextTemplate executes the template `name` with the text engine assigned to
+// the request context, see Engine.Text and Engine.TextGlob, and writes the
+// result to the response without any of the contextual escaping of
+// html/template. It's meant for non-HTML output, such as XML configuration or
+// plain-text emails, and sets the Content-Type as text/plain unless the handler
+// already set one. Never render untrusted data in a HTML page with it.
+func TextTemplate(w http.ResponseWriter, r *http.Request, status int, name string, v any) error {
+	renderer := TextEngineFromCtx(r.Context())
+	if renderer == nil {
+		panic("penguin: text template renderer not assigned")
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.ExecuteTemplate(&buf, name, v); err != nil {
+		return err
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+	return nil
+}
+
+// TextHTML is the same as TextTemplate. It was the first name of the helper and
+// is kept so existing callers keep compiling, but TextTemplate says better that
+// the output isn't escaped as HTML.
+func TextHTML(w http.ResponseWriter, r *http.Request, status int, name string, v any) error {
+	return TextTemplate(w, r, status, name, v)
+}
+
+// HTMLLocalized is like HTML but renders the variant of the template `name`
+// for the request locale when the engine defines one. The locale is read from
+// the request context, see WithLocale, and falls back to the first language of
+// the Accept-Language header. For a `fr-CA` locale rendering `page.tmpl` the
+// templates are tried in the following order:
+//
+//	page.fr-CA.tmpl
+//	page.fr.tmpl
+//	page.tmpl
+//
+// Only engines with a Lookup method, such as html/template, can be searched
+// for a localized variant, any other engine always renders `name`.
+func HTMLLocalized(w http.ResponseWriter, r *http.Request, status int, name string, v any) error {
+	renderer := HTMLEngineFromCtx(r.Context())
+	if renderer == nil {
+		// HTML panics the same way without an engine
+		return HTML(w, r, status, name, v)
+	}
+
+	locale := LocaleFromCtx(r.Context())
+	if locale == "" {
+		locale = acceptLanguage(r)
+	}
+	for _, candidate := range localizedNames(name, locale) {
+		if hasTemplate(renderer, candidate) {
+			return HTML(w, r, status, candidate, v)
+		}
+	}
+	return HTML(w, r, status, name, v)
+}
+
+// localizedNames returns the localized variants of the template `name` for
+// `locale`, from most to least specific.
+func localizedNames(name, locale string) []string {
+	if locale == "" {
 		return nil
 	}
-	panic("penguin: template renderer not assigned")
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	names := []string{base + "." + locale + ext}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		names = append(names, base+"."+locale[:i]+ext)
+	}
+	return names
+}
+
+// acceptLanguage returns the first language tag of the Accept-Language header.
+func acceptLanguage(r *http.Request) string {
+	tag, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	if tag = strings.TrimSpace(tag); tag == "*" {
+		return ""
+	}
+	return tag
 }
 
-// XML marshals 'v' to JSON, setting the Content-Type as application/xml. It
+type htmlLookup interface {
+	Lookup(name string) *htmltemplate.Template
+}
+
+type textLookup interface {
+	Lookup(name string) *texttemplate.Template
+}
+
+// hasTemplate reports whether the engine defines the template `name`, which
+// can only be known for engines with a Lookup method.
+func hasTemplate(engine ExecuteTemplate, name string) bool {
+	switch t := engine.(type) {
+	case htmlLookup:
+		return t.Lookup(name) != nil
+	case textLookup:
+		return t.Lookup(name) != nil
+	}
+	return false
+}
+
+// XML marshals 'v' to XML, setting the Content-Type as application/xml. It
 // will automatically prepend a generic XML header (see encoding/xml.Header) if
 // one is not found in the first 100 bytes of 'v'.
 func XML(w http.ResponseWriter, r *http.Request, status int, v any) error {
@@ -39,7 +289,24 @@ func XML(w http.ResponseWriter, r *http.Request, status int, v any) error {
 	if err != nil {
 		return err
 	}
+	writeXML(w, status, b)
+	return nil
+}
 
+// XMLPretty is like XML but indents the marshalled 'v' by two spaces per
+// level for human-readable responses.
+func XMLPretty(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	writeXML(w, status, b)
+	return nil
+}
+
+// writeXML writes the marshalled XML 'b', prepended by the generic XML header
+// unless 'b' carries its own.
+func writeXML(w http.ResponseWriter, status int, b []byte) {
 	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
 	w.WriteHeader(status)
 
@@ -54,9 +321,166 @@ func XML(w http.ResponseWriter, r *http.Request, status int, v any) error {
 	}
 
 	_, _ = w.Write(b)
+}
+
+// Negotiate renders 'v' as JSON or XML, whichever ranks highest in the request
+// Accept header, preferring JSON when both are equally acceptable or when the
+// request has no Accept header. A 406 Not Acceptable is sent if neither is
+// acceptable.
+func Negotiate(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	switch NegotiateContentType(r, "application/json", "application/xml") {
+	case "application/json":
+		return JSON(w, r, status, v)
+	case "application/xml":
+		return XML(w, r, status, v)
+	}
+	http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+	return nil
+}
+
+// NegotiateHTML is like Negotiate but also offers HTML, executing the template
+// `name` with 'v'. HTML is preferred when it ranks equally with JSON or XML, so
+// browsers sending `Accept: */*` receive the page.
+func NegotiateHTML(w http.ResponseWriter, r *http.Request, status int, name string, v any) error {
+	switch NegotiateContentType(r, "text/html", "application/json", "application/xml") {
+	case "text/html":
+		return HTML(w, r, status, name, v)
+	case "application/json":
+		return JSON(w, r, status, v)
+	case "application/xml":
+		return XML(w, r, status, v)
+	}
+	http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
 	return nil
 }
 
+// NegotiateContentType returns the offered media type that ranks highest by
+// q-value in the request Accept header, or the empty string if none of them are
+// acceptable. Each offer takes the q-value of the most specific media range it
+// matches, and ties go to the offer listed first. A request without an Accept
+// header accepts the first offer.
+func NegotiateContentType(r *http.Request, offers ...string) string {
+	header := r.Header.Values("Accept")
+	if len(header) == 0 {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, v := range header {
+		for _, accept := range strings.Split(v, ",") {
+			mt, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if qv, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(qv, 64); err != nil {
+					continue
+				}
+			}
+			typ, subtype, _ := strings.Cut(mt, "/")
+			ranges = append(ranges, mediaRange{typ, subtype, q})
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+		q, specificity := 0.0, -1
+		for _, mr := range ranges {
+			var s int
+			switch {
+			case mr.typ == typ && mr.subtype == subtype:
+				s = 2
+			case mr.typ == typ && mr.subtype == "*":
+				s = 1
+			case mr.typ == "*" && mr.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = mr.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// HTTPError is an error carrying the HTTP status code and message to respond
+// with. See Error.
+type HTTPError struct {
+	Status  int
+	Message string
+}
+
+// NewHTTPError returns a HTTPError for the `status` code. The message defaults
+// to the status text when empty.
+func NewHTTPError(status int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// Error responds to the request with err. When err, or an error it wraps, has
+// a `StatusCode() int` method its status code is sent, along with the Message
+// of a *HTTPError or the status text for any other error. Any other error is
+// sent as a 500 Internal Server Error. Neither exposes the messages of the
+// errors wrapping it to the client.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	message := http.StatusText(status)
+
+	var se interface{ StatusCode() int }
+	if errors.As(err, &se) {
+		status = se.StatusCode()
+		message = http.StatusText(status)
+		if he, ok := se.(*HTTPError); ok && he.Message != "" {
+			message = he.Message
+		}
+	}
+
+	if IsJSON(r) {
+		_ = JSON(w, r, status, M{"error": message})
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// Redirect replies to the request with a redirect to url, which may be a path
+// relative to the request path, using the `status` code 301, 302, 303, 307 or
+// 308. It panics on any other status, including the 3xx codes that don't
+// redirect such as 304 "Not Modified".
+func Redirect(w http.ResponseWriter, r *http.Request, status int, url string) {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		panic(fmt.Sprintf("penguin: invalid redirect status code %d", status))
+	}
+	http.Redirect(w, r, url, status)
+}
+
 // NoContent returns a HTTP 204 "No Content" response.
 func NoContent(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
@@ -85,6 +509,56 @@ func JSON(w http.ResponseWriter, r *http.Request, status int, v any) error {
 	return nil
 }
 
+// JSONPretty is like JSON but indents the output with `indent`, which defaults
+// to two spaces when empty.
+func JSONPretty(w http.ResponseWriter, r *http.Request, status int, indent string, v any) error {
+	if indent == "" {
+		indent = "  "
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(true)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+	return nil
+}
+
+// jsonpCallback matches the JavaScript identifiers, optionally dotted, that are
+// accepted as a JSONP callback name.
+var jsonpCallback = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// JSONP marshals 'v' to JSON and wraps it in a call to the `callback` function,
+// setting the Content-Type as application/javascript. An empty callback falls
+// back to a plain JSON response, while a callback that isn't a valid JavaScript
+// identifier is rejected with a 400 to prevent script injection.
+func JSONP(w http.ResponseWriter, r *http.Request, status int, callback string, v any) error {
+	if callback == "" {
+		return JSON(w, r, status, v)
+	}
+	if !jsonpCallback.MatchString(callback) {
+		http.Error(w, "invalid JSONP callback", http.StatusBadRequest)
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(true)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(callback + "("))
+	_, _ = w.Write(bytes.TrimRight(buf.Bytes(), "\n"))
+	_, _ = w.Write([]byte(");"))
+	return nil
+}
+
 // PureJSON marshals 'v' to JSON, setting the
 // Content-Type as application/json and without escaping HTML
 func PureJSON(w http.ResponseWriter, r *http.Request, status int, v any) error {
@@ -103,7 +577,137 @@ func PureJSON(w http.ResponseWriter, r *http.Request, status int, v any) error {
 // Data writes raw bytes to the response, setting the Content-Type as
 // application/octet-stream.
 func Data(w http.ResponseWriter, r *http.Request, status int, v []byte) {
-	w.Header().Set("Content-Type", "application/octet-stream")
+	Blob(w, r, status, "application/octet-stream", v)
+}
+
+// Blob writes raw bytes to the response, setting the Content-Type as
+// `contentType`.
+func Blob(w http.ResponseWriter, r *http.Request, status int, contentType string, v []byte) {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
 	_, _ = w.Write(v)
 }
+
+// CSV writes the records to the response as comma-separated values, setting
+// the Content-Type as text/csv. The records are encoded before anything is
+// written, so an encoding error is responded to with a 500 instead.
+func CSV(w http.ResponseWriter, r *http.Request, status int, records [][]string) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.WriteAll(records); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
+
+// CSVFromStructs is like CSV but writes a slice of structs, or pointers to
+// structs, with a header row taken from the `csv` struct tag of each exported
+// field, falling back to the field name. Fields tagged `csv:"-"` are skipped.
+// It panics if 'v' is not a slice of structs.
+func CSVFromStructs(w http.ResponseWriter, r *http.Request, status int, v any) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		panic(fmt.Sprintf("penguin: CSVFromStructs expects a slice of structs, got %T", v))
+	}
+	et := rv.Type().Elem()
+	if et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+	if et.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("penguin: CSVFromStructs expects a slice of structs, got %T", v))
+	}
+
+	var fields []int
+	var header []string
+	for i := 0; i < et.NumField(); i++ {
+		f := et.Field(i)
+		name := f.Tag.Get("csv")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, i)
+		header = append(header, name)
+	}
+
+	records := make([][]string, 0, rv.Len()+1)
+	records = append(records, header)
+	for i := 0; i < rv.Len(); i++ {
+		ev := reflect.Indirect(rv.Index(i))
+		record := make([]string, len(fields))
+		if ev.IsValid() {
+			for j, fi := range fields {
+				record[j] = fmt.Sprint(ev.Field(fi).Interface())
+			}
+		}
+		records = append(records, record)
+	}
+	CSV(w, r, status, records)
+}
+
+// Attachment writes content to the response as a file download with the
+// suggested `filename`, guessing the Content-Type from the filename extension
+// and falling back to application/octet-stream. Non-ASCII filenames are also
+// sent in the RFC 6266 filename* form, with an ASCII approximation for older
+// clients.
+func Attachment(w http.ResponseWriter, r *http.Request, filename string, content []byte) {
+	contentType := mime.TypeByExtension(path.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+// contentDisposition formats a Content-Disposition header value of `kind` for
+// `filename`.
+func contentDisposition(kind, filename string) string {
+	var ascii, encoded strings.Builder
+	needsEncoding := false
+	for i := 0; i < len(filename); i++ {
+		c := filename[i]
+		switch {
+		case c < ' ' || c == 0x7f:
+			// control characters are dropped from both forms
+			continue
+		case c >= utf8.RuneSelf:
+			needsEncoding = true
+			if utf8.RuneStart(c) {
+				ascii.WriteByte('_')
+			}
+		case c == '"' || c == '\\':
+			ascii.WriteByte('\\')
+			ascii.WriteByte(c)
+		default:
+			ascii.WriteByte(c)
+		}
+		if isAttrChar(c) {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+
+	v := kind + `; filename="` + ascii.String() + `"`
+	if needsEncoding {
+		v += "; filename*=UTF-8''" + encoded.String()
+	}
+	return v
+}
+
+// isAttrChar reports whether c may appear unescaped in an RFC 5987 extended
+// parameter value.
+func isAttrChar(c byte) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,730 @@
+package penguin
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	texttemplate "text/template"
+)
+
+func TestHTML(t *testing.T) {
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(`{{define "index"}}<h1>{{.}}</h1>{{end}}`)))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := HTML(w, r, http.StatusCreated, "index", "hello"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expecting status 201, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	if w.Body.String() != "<h1>hello</h1>" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	for _, status := range []int{301, 302, 303, 307, 308} {
+		w := httptest.NewRecorder()
+		Redirect(w, httptest.NewRequest("GET", "/articles/1/edit", nil), status, "../2")
+		if w.Code != status {
+			t.Fatalf("expecting status %d, got %d", status, w.Code)
+		}
+		if loc := w.Header().Get("Location"); loc != "/articles/2" {
+			t.Fatalf("expecting the relative url to be resolved, got %q", loc)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	Redirect(w, httptest.NewRequest("GET", "/", nil), http.StatusFound, "https://example.com/login")
+	if loc := w.Header().Get("Location"); loc != "https://example.com/login" {
+		t.Fatalf("unexpected Location %q", loc)
+	}
+
+	for _, status := range []int{200, 300, 304, 305, 306, 309, 400} {
+		func() {
+			defer func() {
+				if rvr := recover(); rvr == nil {
+					t.Fatalf("expecting Redirect to panic on the status %d", status)
+				}
+			}()
+			Redirect(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), status, "/")
+		}()
+	}
+}
+
+func TestJSONP(t *testing.T) {
+	v := M{"name": "penguin"}
+
+	w := httptest.NewRecorder()
+	if err := JSONP(w, httptest.NewRequest("GET", "/", nil), 200, "widgets.render_1", v); err != nil {
+		t.Fatal(err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/javascript; charset=utf-8" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	if body := w.Body.String(); body != `widgets.render_1({"name":"penguin"});` {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	if err := JSONP(w, httptest.NewRequest("GET", "/", nil), 200, "", v); err != nil {
+		t.Fatal(err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("expecting a plain JSON fallback, got Content-Type %q", ct)
+	}
+	if body := w.Body.String(); body != "{\"name\":\"penguin\"}\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	if err := JSONP(w, httptest.NewRequest("GET", "/", nil), 200, "alert(document.cookie)", v); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expecting 400 for an unsafe callback, got %d", w.Code)
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	tests := []struct {
+		filename, contentType, disposition string
+	}{
+		{"report.json", "application/json", `attachment; filename="report.json"`},
+		{"archive.unknownext", "application/octet-stream", `attachment; filename="archive.unknownext"`},
+		{`say "hi".html`, "text/html; charset=utf-8", `attachment; filename="say \"hi\".html"`},
+		{"résumé.pdf", "application/pdf", `attachment; filename="r_sum_.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		Attachment(w, httptest.NewRequest("GET", "/", nil), tt.filename, []byte("content"))
+
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: Content-Type = %q, expecting %q", tt.filename, ct, tt.contentType)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != tt.disposition {
+			t.Errorf("%s: Content-Disposition = %q, expecting %q", tt.filename, cd, tt.disposition)
+		}
+		if w.Body.String() != "content" {
+			t.Errorf("%s: unexpected body %q", tt.filename, w.Body.String())
+		}
+	}
+}
+
+func TestRenderedTemplates(t *testing.T) {
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(`{{define "header"}}h{{end}}{{define "index"}}i{{end}}`)))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		HTML(w, r, 200, "header", nil)
+		HTML(w, r, 200, "index", nil)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	ctx := RecordTemplates(req.Context())
+	r.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	if names := RenderedTemplates(ctx); len(names) != 2 || names[0] != "header" || names[1] != "index" {
+		t.Fatalf("unexpected rendered templates %v", names)
+	}
+
+	if names := RenderedTemplates(req.Context()); names != nil {
+		t.Fatalf("expecting no templates recorded without RecordTemplates, got %v", names)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	CSV(w, httptest.NewRequest("GET", "/", nil), http.StatusOK, [][]string{
+		{"name", "note"},
+		{"gopher", "likes, commas"},
+	})
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	if body := w.Body.String(); body != "name,note\ngopher,\"likes, commas\"\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestCSVFromStructs(t *testing.T) {
+	type row struct {
+		Name   string `csv:"name"`
+		Age    int
+		Secret string `csv:"-"`
+		hidden string
+	}
+
+	w := httptest.NewRecorder()
+	CSVFromStructs(w, httptest.NewRequest("GET", "/", nil), http.StatusOK, []*row{
+		{Name: "gopher", Age: 13, Secret: "x", hidden: "y"},
+		nil,
+	})
+
+	if body := w.Body.String(); body != "name,Age\ngopher,13\n,\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestBlob(t *testing.T) {
+	w := httptest.NewRecorder()
+	Blob(w, httptest.NewRequest("GET", "/", nil), http.StatusAccepted, "image/png", []byte("\x89PNG"))
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expecting status 202, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	if w.Body.String() != "\x89PNG" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+}
+
+func TestHTMLLocalized(t *testing.T) {
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(
+		`{{define "page.tmpl"}}hello{{end}}` +
+			`{{define "page.fr.tmpl"}}bonjour{{end}}` +
+			`{{define "page.fr-CA.tmpl"}}allô{{end}}`,
+	)))
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if locale := r.URL.Query().Get("locale"); locale != "" {
+				r = r.WithContext(WithLocale(r.Context(), locale))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := HTMLLocalized(w, r, 200, "page.tmpl", nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tests := []struct {
+		url, acceptLanguage, expected string
+	}{
+		{"/", "", "hello"},
+		{"/?locale=fr-CA", "", "allô"},
+		{"/?locale=fr-BE", "", "bonjour"},
+		{"/?locale=fr", "", "bonjour"},
+		{"/?locale=de", "fr", "hello"},
+		{"/", "fr-CH, fr;q=0.9, en;q=0.8", "bonjour"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		req.Header.Set("Accept-Language", tt.acceptLanguage)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != tt.expected {
+			t.Errorf("%s (Accept-Language: %q): got %q, expecting %q", tt.url, tt.acceptLanguage, w.Body.String(), tt.expected)
+		}
+	}
+}
+
+func TestHTMLLocalizedNoRenderer(t *testing.T) {
+	recovered := func(render func(w http.ResponseWriter, r *http.Request, status int, name string, v any) error) (rvr any) {
+		defer func() { rvr = recover() }()
+		render(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), 200, "page.tmpl", nil)
+		return nil
+	}
+
+	expected := recovered(HTML)
+	if expected == nil {
+		t.Fatal("expecting HTML to panic without a template engine")
+	}
+	if rvr := recovered(HTMLLocalized); rvr != expected {
+		t.Fatalf("expecting HTMLLocalized to panic with %v like HTML, got %v", expected, rvr)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"", 200, "application/json; charset=utf-8"},
+		{"application/xml", 200, "application/xml; charset=utf-8"},
+		{"*/*", 200, "application/json; charset=utf-8"},
+		{"application/json;q=0.5, application/xml", 200, "application/xml; charset=utf-8"},
+		{"application/*;q=0.2, application/xml;q=0", 200, "application/json; charset=utf-8"},
+		{"image/png", 406, "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if err := Negotiate(w, req, 200, article{ID: "1", Title: "Hello"}); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("Accept %q: got %d %q, expecting %d %q", tt.accept, w.Code, w.Header().Get("Content-Type"), tt.status, tt.contentType)
+		}
+	}
+}
+
+func TestNegotiateHTML(t *testing.T) {
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(`{{define "index"}}<p>{{.}}</p>{{end}}`)))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := NegotiateHTML(w, r, 200, "index", "hi"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tests := map[string]string{
+		"text/html,application/xhtml+xml,*/*;q=0.8": "text/html; charset=utf-8",
+		"*/*":              "text/html; charset=utf-8",
+		"application/json": "application/json; charset=utf-8",
+		"application/xml":  "application/xml; charset=utf-8",
+		"image/png":        "text/plain; charset=utf-8",
+	}
+	for accept, contentType := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if ct := w.Header().Get("Content-Type"); ct != contentType {
+			t.Errorf("Accept %q: Content-Type %q, expecting %q", accept, ct, contentType)
+		}
+	}
+}
+
+func TestHTMLErr(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := HTMLErr(w, httptest.NewRequest("GET", "/", nil), 200, "index", nil); err != ErrNoRenderer {
+		t.Fatalf("expecting ErrNoRenderer without an engine, got %v", err)
+	}
+
+	var err error
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(`{{define "index"}}ok{{end}}`)))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		err = HTMLErr(w, r, 200, "missing", nil)
+	})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if err == nil {
+		t.Fatal("expecting an error for an undefined template")
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("expecting nothing written on error, got %q", w.Body.String())
+	}
+}
+
+func TestHTMLPanicsWithoutRenderer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting HTML to panic without an engine")
+		}
+	}()
+	HTML(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), 200, "index", nil)
+}
+
+func TestJSONPretty(t *testing.T) {
+	tests := []struct {
+		indent, expected string
+	}{
+		{"", "{\n  \"a\": [\n    1\n  ],\n  \"b\": \"\\u003cb\\u003e\"\n}\n"},
+		{"\t", "{\n\t\"a\": [\n\t\t1\n\t],\n\t\"b\": \"\\u003cb\\u003e\"\n}\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := JSONPretty(w, httptest.NewRequest("GET", "/", nil), http.StatusOK, tt.indent, M{"a": S{1}, "b": "<b>"}); err != nil {
+			t.Fatal(err)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+			t.Fatalf("unexpected Content-Type %q", ct)
+		}
+		if body := w.Body.String(); body != tt.expected {
+			t.Errorf("indent %q: unexpected body %q", tt.indent, body)
+		}
+	}
+}
+
+func TestXMLPretty(t *testing.T) {
+	type item struct {
+		Name string `xml:"name"`
+	}
+	type catalog struct {
+		XMLName struct{} `xml:"catalog"`
+		Items   []item   `xml:"item"`
+	}
+
+	w := httptest.NewRecorder()
+	if err := XMLPretty(w, httptest.NewRequest("GET", "/", nil), http.StatusOK, catalog{Items: []item{{"a"}, {"b"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/xml; charset=utf-8" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		"<catalog>\n  <item>\n    <name>a</name>\n  </item>\n  <item>\n    <name>b</name>\n  </item>\n</catalog>"
+	if body := w.Body.String(); body != expected {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	w = httptest.NewRecorder()
+	if err := XMLPretty(w, httptest.NewRequest("GET", "/", nil), http.StatusOK, make(chan int)); err == nil {
+		t.Fatal("expecting an error marshalling a channel")
+	}
+}
+
+func TestTextHTML(t *testing.T) {
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(`{{define "config"}}<name>{{.}}</name>{{end}}`)))
+	r.Text(texttemplate.Must(texttemplate.New("").Parse(`{{define "config"}}<name>{{.}}</name>{{end}}`)))
+	r.Get("/html", func(w http.ResponseWriter, r *http.Request) {
+		HTML(w, r, 200, "config", "a & b")
+	})
+	r.Get("/text", func(w http.ResponseWriter, r *http.Request) {
+		TextHTML(w, r, 200, "config", "a & b")
+	})
+	r.Get("/xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		TextHTML(w, r, 200, "config", "a & b")
+	})
+
+	tests := []struct {
+		path, contentType, body string
+	}{
+		{"/html", "text/html; charset=utf-8", "<name>a &amp; b</name>"},
+		{"/text", "text/plain; charset=utf-8", "<name>a & b</name>"},
+		{"/xml", "application/xml", "<name>a & b</name>"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: Content-Type %q, expecting %q", tt.path, ct, tt.contentType)
+		}
+		if w.Body.String() != tt.body {
+			t.Errorf("%s: body %q, expecting %q", tt.path, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestHTMLLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.tmpl":  {Data: []byte(`<main>{{block "body" .}}empty{{end}}</main>`)},
+		"page.tmpl":  {Data: []byte(`<h1>{{.title}}</h1>`)},
+		"about.tmpl": {Data: []byte(`<p>about {{.title}}</p>`)},
+	}
+
+	r := New()
+	r.HTMLFs(fsys, "*.tmpl")
+	r.Get("/{page}", func(w http.ResponseWriter, r *http.Request) {
+		if err := HTMLLayout(w, r, 200, "base.tmpl", URLParam(r, "page")+".tmpl", M{"title": "<Penguin>"}); err != nil {
+			w.WriteHeader(500)
+		}
+	})
+	r.Get("/plain", func(w http.ResponseWriter, r *http.Request) {
+		HTML(w, r, 200, "page.tmpl", M{"title": "plain"})
+	})
+
+	tests := []struct {
+		path, expected string
+	}{
+		{"/plain", "<h1>plain</h1>"},
+		{"/page", "<main><h1>&lt;Penguin&gt;</h1></main>"},
+		{"/about", "<main><p>about &lt;Penguin&gt;</p></main>"},
+		{"/page", "<main><h1>&lt;Penguin&gt;</h1></main>"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Body.String() != tt.expected {
+			t.Errorf("GET %s: got %q, expecting %q", tt.path, w.Body.String(), tt.expected)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("GET %s: unexpected Content-Type %q", tt.path, ct)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != 500 {
+		t.Fatalf("expecting an error for a missing content template, got %d", w.Code)
+	}
+}
+
+func TestHTMLFuncs(t *testing.T) {
+	funcs := template.FuncMap{
+		"shout": strings.ToUpper,
+	}
+	fsys := fstest.MapFS{
+		"page.tmpl": {Data: []byte(`{{shout .}}`)},
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(`{{shout .}}!`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.Route("/fs", func(r Router) {
+		r.HTMLFsFuncs(funcs, fsys, "*.tmpl")
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			HTML(w, r, 200, "page.tmpl", "penguin")
+		})
+	})
+	r.Route("/glob", func(r Router) {
+		r.HTMLGlobFuncs(funcs, filepath.Join(dir, "*.tmpl"))
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			HTML(w, r, 200, "page.tmpl", "penguin")
+		})
+	})
+
+	for path, expected := range map[string]string{"/fs": "PENGUIN", "/glob": "PENGUIN!"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Body.String() != expected {
+			t.Errorf("GET %s: got %q, expecting %q", path, w.Body.String(), expected)
+		}
+	}
+}
+
+func TestHTMLDelims(t *testing.T) {
+	const page = `<div id="app">{{ message }}</div><p>[[.]]</p>`
+	fsys := fstest.MapFS{"page.tmpl": {Data: []byte(page)}}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.Route("/fs", func(r Router) {
+		r.HTMLFsDelims("[[", "]]", fsys, "*.tmpl")
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			HTML(w, r, 200, "page.tmpl", "penguin")
+		})
+	})
+	r.Route("/glob", func(r Router) {
+		r.HTMLGlobDelims("[[", "]]", filepath.Join(dir, "*.tmpl"))
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			HTML(w, r, 200, "page.tmpl", "penguin")
+		})
+	})
+
+	for _, path := range []string{"/fs", "/glob"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if expected := `<div id="app">{{ message }}</div><p>penguin</p>`; w.Body.String() != expected {
+			t.Errorf("GET %s: got %q, expecting %q", path, w.Body.String(), expected)
+		}
+	}
+}
+
+func TestTextGlob(t *testing.T) {
+	const email = `Hello {{.}}, <reply> to unsubscribe`
+	fsys := fstest.MapFS{"email.txt": {Data: []byte(email)}}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "email.txt"), []byte(email), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.Route("/fs", func(r Router) {
+		r.TextFs(fsys, "*.txt")
+		r.HTMLFs(fsys, "*.txt")
+		r.Get("/text", func(w http.ResponseWriter, r *http.Request) {
+			TextTemplate(w, r, 200, "email.txt", "<b>Jo</b>")
+		})
+		r.Get("/html", func(w http.ResponseWriter, r *http.Request) {
+			HTML(w, r, 200, "email.txt", "<b>Jo</b>")
+		})
+	})
+	r.Route("/glob", func(r Router) {
+		r.TextGlob(filepath.Join(dir, "*.txt"))
+		r.Get("/text", func(w http.ResponseWriter, r *http.Request) {
+			TextTemplate(w, r, 200, "email.txt", "<b>Jo</b>")
+		})
+	})
+
+	tests := []struct {
+		path, contentType, body string
+	}{
+		{"/fs/text", "text/plain; charset=utf-8", "Hello <b>Jo</b>, <reply> to unsubscribe"},
+		{"/glob/text", "text/plain; charset=utf-8", "Hello <b>Jo</b>, <reply> to unsubscribe"},
+		{"/fs/html", "text/html; charset=utf-8", "Hello &lt;b&gt;Jo&lt;/b&gt;, <reply> to unsubscribe"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: Content-Type %q, expecting %q", tt.path, ct, tt.contentType)
+		}
+		if w.Body.String() != tt.body {
+			t.Errorf("%s: body %q, expecting %q", tt.path, w.Body.String(), tt.body)
+		}
+	}
+}
+
+// writeCounter records the size of each write made to a response.
+type writeCounter struct {
+	*httptest.ResponseRecorder
+	writes []int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, len(p))
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestHTMLStream(t *testing.T) {
+	r := New()
+	r.HTML(template.Must(template.New("").Parse(
+		`{{define "list"}}<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}` +
+			`{{define "broken"}}<p>before</p>{{.Missing}}{{end}}`,
+	)))
+
+	items := make([]int, 10000)
+	var expected strings.Builder
+	expected.WriteString("<ul>")
+	for i := range items {
+		items[i] = i
+		expected.WriteString("<li>" + strconv.Itoa(i) + "</li>")
+	}
+	expected.WriteString("</ul>")
+
+	var streamErr error
+	r.Get("/list", func(w http.ResponseWriter, r *http.Request) {
+		streamErr = HTMLStream(w, r, 200, "list", items)
+	})
+	r.Get("/broken", func(w http.ResponseWriter, r *http.Request) {
+		streamErr = HTMLStream(w, r, 200, "broken", 42)
+	})
+
+	w := &writeCounter{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/list", nil))
+	if streamErr != nil {
+		t.Fatal(streamErr)
+	}
+	if w.Body.String() != expected.String() {
+		t.Fatal("streamed body doesn't match the template output")
+	}
+	if len(w.writes) < 2 {
+		t.Fatalf("expecting the page to be written as it executes, got %d writes", len(w.writes))
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+
+	w = &writeCounter{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/broken", nil))
+	if streamErr == nil {
+		t.Fatal("expecting the execution error to be returned")
+	}
+	if w.Code != 200 || w.Body.String() != "<p>before</p>" {
+		t.Fatalf("expecting the partial page with the sent status, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// fakeEngine renders every template as its name followed by the data.
+type fakeEngine struct {
+	calls []string
+}
+
+func (e *fakeEngine) ExecuteTemplate(w io.Writer, name string, data any) error {
+	e.calls = append(e.calls, name)
+	if name == "missing" {
+		return errors.New("no such template")
+	}
+	_, err := fmt.Fprintf(w, "%s:%v", name, data)
+	return err
+}
+
+func TestHTMLEngine(t *testing.T) {
+	engine := &fakeEngine{}
+	r := New()
+	r.HTMLEngine(engine)
+	r.Get("/html", func(w http.ResponseWriter, r *http.Request) {
+		HTML(w, r, 200, "page", "a")
+	})
+	r.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		HTMLStream(w, r, 200, "stream", "b")
+	})
+	r.Get("/missing", func(w http.ResponseWriter, r *http.Request) {
+		if err := HTMLErr(w, r, 200, "missing", nil); err != nil {
+			w.WriteHeader(500)
+		}
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/html", 200, "page:a"},
+		{"/stream", 200, "stream:b"},
+		{"/missing", 500, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, expecting %d %q", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+	if strings.Join(engine.calls, ",") != "page,stream,missing" {
+		t.Fatalf("unexpected engine calls %v", engine.calls)
+	}
+}
+
+func TestHTMLGlobE(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(`{{ if }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "page.txt"), []byte(`ok`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := fstest.MapFS{"broken.tmpl": {Data: []byte(`{{ if }}`)}}
+
+	r := New()
+	if err := r.HTMLGlobE(filepath.Join(dir, "*.tmpl")); err == nil {
+		t.Fatal("expecting HTMLGlobE to return the parse error")
+	}
+	if err := r.HTMLGlobE(filepath.Join(dir, "*.missing")); err == nil {
+		t.Fatal("expecting HTMLGlobE to return an error for a pattern matching no files")
+	}
+	if err := r.HTMLFsE(broken, "*.tmpl"); err == nil {
+		t.Fatal("expecting HTMLFsE to return the parse error")
+	}
+	if err := r.HTMLGlobE(filepath.Join(dir, "*.txt")); err != nil {
+		t.Fatal(err)
+	}
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		HTML(w, r, 200, "page.txt", nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != "ok" {
+		t.Fatalf("expecting the valid templates to be served, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,64 @@
+package penguin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ShutdownTimeout is the grace period Engine.Run gives in-flight requests to
+// complete once the server is asked to stop.
+var ShutdownTimeout = 10 * time.Second
+
+// ListenAndServe listens on the TCP network address addr and serves the Engine
+// until the process receives SIGINT or SIGTERM, then shuts the server down
+// gracefully. See Run.
+func (mx *Engine) ListenAndServe(addr string) error {
+	return mx.Run(context.Background(), addr)
+}
+
+// Run listens on the TCP network address addr and serves the Engine until ctx
+// is done or the process receives SIGINT or SIGTERM. It then stops accepting
+// connections and waits up to ShutdownTimeout for in-flight requests to
+// complete.
+//
+// Run returns the error that stopped the listener, if any, otherwise the error
+// of the shutdown, which is nil when all requests completed in time.
+func (mx *Engine) Run(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return mx.serve(ctx, ln)
+}
+
+// serve is Run on an existing listener, which is closed on return.
+func (mx *Engine) serve(ctx context.Context, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Handler: mx}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if serveErr := <-errc; !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package penguin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEngineRun(t *testing.T) {
+	started := make(chan struct{})
+	r := New()
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	r.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- r.serve(ctx, ln)
+	}()
+
+	url := "http://" + ln.Addr().String()
+	if body := getBody(t, url+"/"); body != "ok" {
+		t.Fatalf("expecting ok, got %q", body)
+	}
+
+	// An in-flight request completes during the grace period.
+	bodyc := make(chan string, 1)
+	go func() {
+		bodyc <- getBody(t, url+"/slow")
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("expecting a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop")
+	}
+	if body := <-bodyc; body != "done" {
+		t.Fatalf("expecting the in-flight request to complete, got %q", body)
+	}
+
+	if _, err := http.Get(url + "/"); err == nil {
+		t.Fatal("expecting the server to be stopped")
+	}
+}
+
+func TestEngineRunListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	err = New().Run(context.Background(), ln.Addr().String())
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("expecting a listen error, got %v", err)
+	}
+}
+
+func getBody(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Error(err)
+		return ""
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return string(b)
+}
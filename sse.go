@@ -0,0 +1,80 @@
+package penguin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrStreamingUnsupported is returned by NewEventStream when the response
+// writer can't be flushed, as events would otherwise sit in a buffer.
+var ErrStreamingUnsupported = errors.New("penguin: http.Flusher is unavailable on the writer")
+
+// ErrInvalidEvent is returned by EventStream.Send when the event name contains
+// a line break, which would let it inject fields into the stream.
+var ErrInvalidEvent = errors.New("penguin: event name contains a line break")
+
+// eventLineBreaks normalizes the CRLF, CR and LF line endings of event data.
+var eventLineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// EventStream writes Server-Sent Events to a client, flushing each event to
+// the connection as soon as it's sent.
+type EventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// NewEventStream sets the Server-Sent Events response headers on w and returns
+// an EventStream writing to it. It returns ErrStreamingUnsupported if w doesn't
+// implement http.Flusher.
+func NewEventStream(w http.ResponseWriter) (*EventStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrStreamingUnsupported
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &EventStream{w: w, flusher: flusher}, nil
+}
+
+// Send writes an event with the given name and data to the stream. The event
+// line is omitted when `event` is empty, and each line of a multi-line `data`,
+// ended by any of CRLF, CR or LF, is sent as its own data field. It returns
+// ErrInvalidEvent if `event` contains a CR or LF.
+func (s *EventStream) Send(event, data string) error {
+	if strings.ContainsAny(event, "\r\n") {
+		return ErrInvalidEvent
+	}
+
+	var b strings.Builder
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(eventLineBreaks.Replace(data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	if _, err := s.w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// SendJSON marshals 'v' to JSON and sends it as the data of an event.
+func (s *EventStream) SendJSON(event string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Send(event, string(b))
+}
@@ -0,0 +1,74 @@
+package penguin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestEventStream(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	stream, err := NewEventStream(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send("x", "y"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send("", "multi\nline"); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.SendJSON("user", M{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected Content-Type %q", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("unexpected Cache-Control %q", cc)
+	}
+	if !w.Flushed {
+		t.Fatal("expecting the stream to be flushed")
+	}
+
+	expected := "event: x\ndata: y\n\n" +
+		"data: multi\ndata: line\n\n" +
+		"event: user\ndata: {\"id\":1}\n\n"
+	if body := w.Body.String(); body != expected {
+		t.Fatalf("unexpected stream %q", body)
+	}
+}
+
+func TestEventStreamLineBreaks(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream, err := NewEventStream(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range []string{"x\ndata: injected", "x\r", "x\r\ny"} {
+		if err := stream.Send(event, "y"); err != ErrInvalidEvent {
+			t.Fatalf("%q: expecting ErrInvalidEvent, got %v", event, err)
+		}
+	}
+	if err := stream.Send("", "a\r\nb\rc\nd"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "data: a\ndata: b\ndata: c\ndata: d\n\n"
+	if body := w.Body.String(); body != expected {
+		t.Fatalf("unexpected stream %q", body)
+	}
+}
+
+func TestEventStreamUnsupported(t *testing.T) {
+	if _, err := NewEventStream(nonFlushingWriter{httptest.NewRecorder()}); err != ErrStreamingUnsupported {
+		t.Fatalf("expecting ErrStreamingUnsupported, got %v", err)
+	}
+}
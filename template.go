@@ -0,0 +1,189 @@
+package penguin
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// LayoutBlock is the name of the block in a layout template that HTMLLayout
+// fills with the content template.
+var LayoutBlock = "body"
+
+// layoutExecutor is implemented by engines that can execute a layout template
+// with a content template filling its LayoutBlock.
+type layoutExecutor interface {
+	ExecuteLayout(w io.Writer, layout, content string, data any) error
+}
+
+// htmlSet is the html/template set built by Engine.HTMLGlob and Engine.HTMLFs.
+// It keeps an unexecuted copy of the set, since html/template can't be cloned
+// once executed, from which the layout and content pairs of HTMLLayout are
+// cloned on first use.
+type htmlSet struct {
+	*htmltemplate.Template
+
+	pristine *htmltemplate.Template
+	mu       sync.Mutex
+	layouts  map[[2]string]*htmltemplate.Template
+}
+
+func newHTMLSet(tmpl *htmltemplate.Template) *htmlSet {
+	return &htmlSet{
+		Template: tmpl,
+		pristine: htmltemplate.Must(tmpl.Clone()),
+		layouts:  map[[2]string]*htmltemplate.Template{},
+	}
+}
+
+// ExecuteLayout executes the template `layout` with its LayoutBlock replaced
+// by the template `content`.
+func (s *htmlSet) ExecuteLayout(w io.Writer, layout, content string, data any) error {
+	key := [2]string{layout, content}
+	s.mu.Lock()
+	tmpl, ok := s.layouts[key]
+	if !ok {
+		var err error
+		if tmpl, err = withLayoutBlock(s.pristine, content); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.layouts[key] = tmpl
+	}
+	s.mu.Unlock()
+	return tmpl.ExecuteTemplate(w, layout, data)
+}
+
+// withLayoutBlock returns a clone of tmpl with its LayoutBlock replaced by the
+// template `content`. It fails if tmpl has already been executed.
+func withLayoutBlock(tmpl *htmltemplate.Template, content string) (*htmltemplate.Template, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	t := clone.Lookup(content)
+	if t == nil || t.Tree == nil {
+		return nil, fmt.Errorf("penguin: no content template %q", content)
+	}
+	if _, err := clone.AddParseTree(LayoutBlock, t.Tree); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// htmlWatcher holds the html/template set of Engine.HTMLGlobWatch, reparsing it
+// whenever a file matching one of its patterns changes.
+type htmlWatcher struct {
+	patterns []string
+	done     chan struct{} // closed once the watcher has stopped
+
+	mu  sync.RWMutex
+	set *htmlSet
+}
+
+// newHTMLWatcher parses the templates matching patterns and starts watching the
+// directories of the patterns for changes until ctx is done. It panics if the
+// templates fail to parse or the directories can't be watched.
+func newHTMLWatcher(ctx context.Context, patterns []string) *htmlWatcher {
+	hw := &htmlWatcher{patterns: patterns, done: make(chan struct{})}
+	set, err := hw.parse()
+	if err != nil {
+		panic(err)
+	}
+	hw.set = set
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		panic(fmt.Sprintf("penguin: can't watch templates: %v", err))
+	}
+	dirs := map[string]bool{}
+	for _, pattern := range patterns {
+		dir := filepath.Dir(pattern)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			panic(fmt.Sprintf("penguin: can't watch templates in '%s': %v", dir, err))
+		}
+	}
+	go hw.watch(ctx, watcher)
+	return hw
+}
+
+func (hw *htmlWatcher) parse() (*htmlSet, error) {
+	tmpl := htmltemplate.New("")
+	for _, pattern := range hw.patterns {
+		var err error
+		if tmpl, err = tmpl.ParseGlob(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return newHTMLSet(tmpl), nil
+}
+
+// htmlWatchDelay is how long the watcher waits for a burst of file events, such
+// as an editor truncating then writing a file, to settle before reparsing.
+var htmlWatchDelay = 100 * time.Millisecond
+
+func (hw *htmlWatcher) watch(ctx context.Context, watcher *fsnotify.Watcher) {
+	defer close(hw.done)
+	defer watcher.Close()
+	var changed string
+	timer := time.NewTimer(htmlWatchDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod || !hw.matches(event.Name) {
+				continue
+			}
+			changed = event.Name
+			timer.Reset(htmlWatchDelay)
+		case <-timer.C:
+			set, err := hw.parse()
+			if err != nil {
+				log.Printf("penguin: keeping the previous templates, reparsing after a change to '%s' failed: %v", changed, err)
+				continue
+			}
+			hw.mu.Lock()
+			hw.set = set
+			hw.mu.Unlock()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("penguin: watching templates: %v", err)
+		}
+	}
+}
+
+// matches reports whether the file name matches one of the patterns.
+func (hw *htmlWatcher) matches(name string) bool {
+	for _, pattern := range hw.patterns {
+		if ok, _ := filepath.Match(filepath.Clean(pattern), filepath.Clean(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// current returns the last template set that parsed successfully.
+func (hw *htmlWatcher) current() *htmlSet {
+	hw.mu.RLock()
+	defer hw.mu.RUnlock()
+	return hw.set
+}
@@ -0,0 +1,130 @@
+package penguin
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHTMLGlobWatch(t *testing.T) {
+	var logs syncBuffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.tmpl")
+	write := func(content string) {
+		if err := os.WriteFile(page, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("v1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var engines []ExecuteTemplate
+	r := New()
+	r.HTMLGlobWatchContext(ctx, filepath.Join(dir, "*.tmpl"))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		engines = append(engines, HTMLEngineFromCtx(r.Context()))
+		HTML(w, r, 200, "page.tmpl", nil)
+	})
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w.Body.String()
+	}
+	waitFor := func(expected string) {
+		deadline := time.Now().Add(5 * time.Second)
+		for get() != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("expecting %q to be served after the change", expected)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if body := get(); body != "v1" {
+		t.Fatalf("expecting v1, got %q", body)
+	}
+	get()
+	if engines[0] != engines[1] {
+		t.Fatal("expecting the templates not to be reparsed without a change")
+	}
+
+	write("v2")
+	waitFor("v2")
+
+	// A broken template keeps the last good one in service.
+	write("{{ broken")
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "change to '"+page+"' failed") {
+		if time.Now().After(deadline) {
+			t.Fatal("expecting the parse error to be logged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if body := get(); body != "v2" {
+		t.Fatalf("expecting the last good template, got %q", body)
+	}
+
+	write("v3")
+	waitFor("v3")
+}
+
+func TestHTMLGlobWatchStopsWithContext(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.tmpl")
+	if err := os.WriteFile(page, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hw := newHTMLWatcher(ctx, []string{filepath.Join(dir, "*.tmpl")})
+	cancel()
+	select {
+	case <-hw.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expecting the watcher to stop once the context is done")
+	}
+
+	// The last templates keep being served after the watcher stopped.
+	if err := os.WriteFile(page, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * htmlWatchDelay)
+	var buf bytes.Buffer
+	if err := hw.current().ExecuteTemplate(&buf, "page.tmpl", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "v1" {
+		t.Fatalf("expecting v1 after the watcher stopped, got %q", buf.String())
+	}
+}
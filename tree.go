@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type methodTyp uint
@@ -62,6 +63,42 @@ func RegisterMethod(method string) {
 	mALL |= mt
 }
 
+// IsMethod reports whether method is a standard HTTP method or one added with
+// RegisterMethod. The method is matched case-insensitively.
+func IsMethod(method string) bool {
+	_, ok := methodMap[strings.ToUpper(method)]
+	return ok
+}
+
+// paramTypes holds the regexp shorthands registered with RegisterParamType,
+// guarded by paramTypesMu.
+var (
+	paramTypes   = map[string]string{}
+	paramTypesMu sync.RWMutex
+)
+
+// RegisterParamType registers `name` as a shorthand for the `regex` of route
+// params, so a pattern like "/users/{id:uuid}" matches the param against the
+// regexp registered as "uuid". It returns an error if `regex` doesn't compile.
+//
+// A registered name takes precedence over a param regexp with the same text,
+// so pick names that aren't valid regexps of their own, such as "uuid". The
+// shorthand is resolved when a route is registered, so call it before the
+// routes that use it are registered: routes registered earlier keep matching
+// the param against the name's text as a regexp. It is safe for concurrent use.
+func RegisterParamType(name, regex string) error {
+	if name == "" {
+		return fmt.Errorf("penguin: param type name is empty")
+	}
+	if _, err := regexp.Compile(regex); err != nil {
+		return fmt.Errorf("penguin: invalid regexp for param type '%s': %w", name, err)
+	}
+	paramTypesMu.Lock()
+	paramTypes[name] = regex
+	paramTypesMu.Unlock()
+	return nil
+}
+
 type nodeTyp uint8
 
 const (
@@ -123,6 +160,18 @@ func (s endpoints) Value(method methodTyp) *endpoint {
 }
 
 func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handler) *node {
+	if short, full, ok := patOptionalSegment(pattern); ok {
+		// Both endpoints keep the declared pattern, so the route is reported,
+		// looked up and removed as the one route it was registered as.
+		n.insertRoute(method, short, pattern, handler)
+		return n.insertRoute(method, full, pattern, handler)
+	}
+	return n.insertRoute(method, pattern, pattern, handler)
+}
+
+// insertRoute inserts the endpoint for the routing `pattern` in the tree,
+// recording `declared` as the pattern the route was registered with.
+func (n *node) insertRoute(method methodTyp, pattern, declared string, handler http.Handler) *node {
 	var parent *node
 	search := pattern
 
@@ -130,7 +179,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handle
 		// Handle key exhaustion
 		if len(search) == 0 {
 			// Insert or update the node's leaf handler
-			n.setEndpoint(method, handler, pattern)
+			n.setEndpoint(method, handler, pattern, declared)
 			return n
 		}
 
@@ -158,7 +207,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handle
 		if n == nil {
 			child := &node{label: label, tail: segTail, prefix: search}
 			hn := parent.addChild(child, search)
-			hn.setEndpoint(method, handler, pattern)
+			hn.setEndpoint(method, handler, pattern, declared)
 
 			return hn
 		}
@@ -198,7 +247,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handle
 		// If the new key is a subset, set the method/handler on this node and finish.
 		search = search[commonPrefix:]
 		if len(search) == 0 {
-			child.setEndpoint(method, handler, pattern)
+			child.setEndpoint(method, handler, pattern, declared)
 			return child
 		}
 
@@ -209,7 +258,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler http.Handle
 			prefix: search,
 		}
 		hn := child.addChild(subchild, search)
-		hn.setEndpoint(method, handler, pattern)
+		hn.setEndpoint(method, handler, pattern, declared)
 		return hn
 	}
 }
@@ -327,8 +376,9 @@ func (n *node) getEdge(ntyp nodeTyp, label, tail byte, prefix string) *node {
 	return nil
 }
 
-func (n *node) setEndpoint(method methodTyp, handler http.Handler, pattern string) {
-	// Set the handler for the method type on the node
+// setEndpoint sets the handler for the method type on the node, with the param
+// keys of the routing `pattern` and the `declared` pattern of the route.
+func (n *node) setEndpoint(method methodTyp, handler http.Handler, pattern, declared string) {
 	if n.endpoints == nil {
 		n.endpoints = make(endpoints)
 	}
@@ -341,22 +391,123 @@ func (n *node) setEndpoint(method methodTyp, handler http.Handler, pattern strin
 	if method&mALL == mALL {
 		h := n.endpoints.Value(mALL)
 		h.handler = handler
-		h.pattern = pattern
+		h.pattern = declared
 		h.paramKeys = paramKeys
 		for _, m := range methodMap {
 			h := n.endpoints.Value(m)
 			h.handler = handler
-			h.pattern = pattern
+			h.pattern = declared
 			h.paramKeys = paramKeys
 		}
 	} else {
 		h := n.endpoints.Value(method)
 		h.handler = handler
-		h.pattern = pattern
+		h.pattern = declared
 		h.paramKeys = paramKeys
 	}
 }
 
+// RemoveRoute deletes the endpoint for `method` from the node registered with
+// the exact routing `pattern`, and reports whether an endpoint was removed.
+// Nodes left without endpoints or children are pruned from the tree.
+func (n *node) RemoveRoute(method methodTyp, pattern string) bool {
+	if n.endpoints != nil {
+		if h := n.endpoints[method]; h != nil && h.handler != nil && h.pattern == pattern {
+			delete(n.endpoints, method)
+			if !n.endpoints.hasHandlers() {
+				n.endpoints = nil
+			}
+			return true
+		}
+	}
+
+	for _, nds := range n.children {
+		for _, cn := range nds {
+			if cn.RemoveRoute(method, pattern) {
+				n.pruneChild(cn)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasRoute reports whether an endpoint is registered for the `method` on the
+// exact routing `pattern` in the tree.
+func (n *node) HasRoute(method methodTyp, pattern string) bool {
+	if h := n.endpoints[method]; h != nil && h.handler != nil && h.pattern == pattern {
+		return true
+	}
+	for _, nds := range n.children {
+		for _, cn := range nds {
+			if cn.HasRoute(method, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pruneChild removes the `child` node when it no longer holds endpoints,
+// subroutes or children of its own. An emptied static node with a single
+// static child is merged with that child to keep the radix tree compressed.
+func (n *node) pruneChild(child *node) {
+	if child.endpoints != nil || child.subroutes != nil {
+		return
+	}
+
+	var grandchild *node
+	count := 0
+	for _, nds := range child.children {
+		count += len(nds)
+		if len(nds) > 0 {
+			grandchild = nds[0]
+		}
+	}
+
+	switch {
+	case count == 0:
+		nds := n.children[child.typ]
+		for i := range nds {
+			if nds[i] == child {
+				n.children[child.typ] = append(nds[:i], nds[i+1:]...)
+				return
+			}
+		}
+
+	case count == 1 && child.typ == ntStatic && grandchild.typ == ntStatic:
+		child.prefix += grandchild.prefix
+		child.children = grandchild.children
+		child.endpoints = grandchild.endpoints
+		child.subroutes = grandchild.subroutes
+	}
+}
+
+// hasHandlers reports whether any http method, other than the catch-all and
+// stub markers, still has a handler assigned.
+func (s endpoints) hasHandlers() bool {
+	for mt, h := range s {
+		if mt == mSTUB || mt == mALL {
+			continue
+		}
+		if h.handler != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMethods appends the http methods, other than the catch-all and stub
+// markers, that have a handler assigned to mts.
+func (s endpoints) appendMethods(mts []methodTyp) []methodTyp {
+	for mt, h := range s {
+		if mt != mSTUB && mt != mALL && h.handler != nil {
+			mts = append(mts, mt)
+		}
+	}
+	return mts
+}
+
 func (n *node) FindRoute(rctx *Context, method methodTyp, path string) (*node, endpoints, http.Handler) {
 	// Reset the context routing pattern and params
 	rctx.routePattern = ""
@@ -457,6 +608,7 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 						// flag that the routing context found a route, but not a corresponding
 						// supported method
 						rctx.methodNotAllowed = true
+						rctx.methodsAllowed = xn.endpoints.appendMethods(rctx.methodsAllowed)
 					}
 				}
 
@@ -496,6 +648,7 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 				// flag that the routing context found a route, but not a corresponding
 				// supported method
 				rctx.methodNotAllowed = true
+				rctx.methodsAllowed = xn.endpoints.appendMethods(rctx.methodsAllowed)
 			}
 		}
 
@@ -593,6 +746,9 @@ func (n *node) findPattern(pattern string) bool {
 
 func (n *node) routes() []Route {
 	rts := []Route{}
+	// Index of the route of each pattern in rts, since the endpoints of a
+	// pattern with an optional segment are held by two nodes.
+	idx := map[string]int{}
 
 	n.walk(func(eps endpoints, subroutes Routes) bool {
 		if eps[mSTUB] != nil && eps[mSTUB].handler != nil && subroutes == nil {
@@ -631,6 +787,13 @@ func (n *node) routes() []Route {
 				hs[m] = h.handler
 			}
 
+			if i, ok := idx[p]; ok && subroutes == nil && rts[i].SubRoutes == nil {
+				for m, h := range hs {
+					rts[i].Handlers[m] = h
+				}
+				continue
+			}
+			idx[p] = len(rts)
 			rt := Route{subroutes, hs, p}
 			rts = append(rts, rt)
 		}
@@ -709,6 +872,15 @@ func patNextSegment(pattern string) (nodeTyp, string, string, byte, int, int) {
 			nt = ntRegexp
 			rexpat = key[idx+1:]
 			key = key[:idx]
+			paramTypesMu.RLock()
+			if rex, ok := paramTypes[rexpat]; ok {
+				rexpat = rex
+			}
+			paramTypesMu.RUnlock()
+		}
+
+		if strings.HasSuffix(key, "?") {
+			panic(fmt.Sprintf("penguin: optional route param '%s' must be the last segment of the pattern", key))
 		}
 
 		if len(rexpat) > 0 {
@@ -730,6 +902,31 @@ func patNextSegment(pattern string) (nodeTyp, string, string, byte, int, int) {
 	return ntCatchAll, "*", "", 0, ws, len(pattern)
 }
 
+// patOptionalSegment splits a pattern ending with an optional param segment,
+// such as "/files/{name?}" or "/files/{name?:[a-z]+}", into the pattern
+// without the segment, "/files", and the pattern with a required param,
+// "/files/{name}".
+func patOptionalSegment(pattern string) (short, full string, ok bool) {
+	ps := strings.LastIndex(pattern, "/{") + 1
+	if ps == 0 || !strings.HasSuffix(pattern, "}") {
+		return "", "", false
+	}
+	key, rexpat, hasRexpat := strings.Cut(pattern[ps+1:len(pattern)-1], ":")
+	if !strings.HasSuffix(key, "?") || strings.ContainsAny(key, "{}") {
+		return "", "", false
+	}
+
+	short = pattern[:ps-1]
+	if short == "" {
+		short = "/"
+	}
+	full = pattern[:ps+1] + strings.TrimSuffix(key, "?")
+	if hasRexpat {
+		full += ":" + rexpat
+	}
+	return short, full + "}", true
+}
+
 func patParamKeys(pattern string) []string {
 	pat := pattern
 	paramKeys := []string{}
@@ -828,6 +1025,40 @@ func Walk(r Routes, walkFn WalkFunc) error {
 	return walk(r, walkFn, "")
 }
 
+// WalkSorted is like Walk but calls walkFn for the routes sorted by their
+// pattern and then by method, which makes for stable, readable route listings.
+// It collects every route before the first call to walkFn.
+func WalkSorted(r Routes, walkFn WalkFunc) error {
+	type walkedRoute struct {
+		method, route string
+		handler       http.Handler
+		middlewares   []func(http.Handler) http.Handler
+	}
+
+	var routes []walkedRoute
+	err := Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routes = append(routes, walkedRoute{method, route, handler, middlewares})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].route != routes[j].route {
+			return routes[i].route < routes[j].route
+		}
+		return routes[i].method < routes[j].method
+	})
+
+	for _, wr := range routes {
+		if err := walkFn(wr.method, wr.route, wr.handler, wr.middlewares...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func walk(r Routes, walkFn WalkFunc, parentRoute string, parentMw ...func(http.Handler) http.Handler) error {
 	for _, route := range r.Routes() {
 		mws := make([]func(http.Handler) http.Handler, len(parentMw))
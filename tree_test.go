@@ -414,6 +414,105 @@ func TestTreeRegexMatchWholeParam(t *testing.T) {
 	}
 }
 
+func TestRegisterParamType(t *testing.T) {
+	if err := RegisterParamType("uuid", `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		paramTypesMu.Lock()
+		delete(paramTypes, "uuid")
+		paramTypesMu.Unlock()
+	})
+	if err := RegisterParamType("broken", `[0-9`); err == nil {
+		t.Fatal("expecting an error registering an invalid regexp")
+	}
+	if err := RegisterParamType("", `.+`); err == nil {
+		t.Fatal("expecting an error registering an empty name")
+	}
+
+	hStub := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tr := &node{}
+	tr.InsertRoute(mGET, "/users/{id:uuid}", hStub)
+
+	tests := []struct {
+		url      string
+		expected http.Handler
+		id       string
+	}{
+		{"/users/0b3c6a5e-2f4d-4c8b-9a1e-7d6f5e4c3b2a", hStub, "0b3c6a5e-2f4d-4c8b-9a1e-7d6f5e4c3b2a"},
+		{"/users/42", nil, ""},
+		{"/users/uuid", nil, ""},
+	}
+	for _, tt := range tests {
+		rctx := NewRouteContext()
+		_, _, handler := tr.FindRoute(rctx, mGET, tt.url)
+		if fmt.Sprintf("%v", tt.expected) != fmt.Sprintf("%v", handler) {
+			t.Errorf("url %v: expecting handler:%v , got:%v", tt.url, tt.expected, handler)
+		}
+		if id := rctx.URLParam("id"); id != tt.id {
+			t.Errorf("url %v: expecting id %q, got %q", tt.url, tt.id, id)
+		}
+	}
+}
+
+func TestTreeOptionalSegment(t *testing.T) {
+	hFiles := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	hPage := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	hRoot := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	hStatic := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tr := &node{}
+	tr.InsertRoute(mGET, "/files/{name?}", hFiles)
+	tr.InsertRoute(mGET, "/pages/{n?:[0-9]+}", hPage)
+	tr.InsertRoute(mGET, "/{lang?}", hRoot)
+	tr.InsertRoute(mGET, "/static/*", hStatic)
+
+	tests := []struct {
+		url      string
+		expected http.Handler
+		key      string
+		value    string
+		pattern  string
+	}{
+		{"/files/report.pdf", hFiles, "name", "report.pdf", "/files/{name?}"},
+		{"/files", hFiles, "name", "", "/files/{name?}"},
+		{"/pages/2", hPage, "n", "2", "/pages/{n?:[0-9]+}"},
+		{"/pages", hPage, "n", "", "/pages/{n?:[0-9]+}"},
+		{"/pages/two", nil, "n", "", ""},
+		{"/en", hRoot, "lang", "en", "/{lang?}"},
+		{"/", hRoot, "lang", "", "/{lang?}"},
+		{"/static/css/app.css", hStatic, "*", "css/app.css", "/static/*"},
+	}
+	for _, tt := range tests {
+		rctx := NewRouteContext()
+		_, _, handler := tr.FindRoute(rctx, mGET, tt.url)
+		if fmt.Sprintf("%v", tt.expected) != fmt.Sprintf("%v", handler) {
+			t.Errorf("url %v: expecting handler:%v , got:%v", tt.url, tt.expected, handler)
+		}
+		if v := rctx.URLParam(tt.key); v != tt.value {
+			t.Errorf("url %v: expecting %s param %q, got %q", tt.url, tt.key, tt.value, v)
+		}
+		if handler != nil && rctx.routePattern != tt.pattern {
+			t.Errorf("url %v: expecting pattern %q, got %q", tt.url, tt.pattern, rctx.routePattern)
+		}
+	}
+
+	if !tr.HasRoute(mGET, "/files/{name?}") || tr.HasRoute(mGET, "/files/{name}") {
+		t.Error("expecting only the declared optional pattern to be registered")
+	}
+	if routes := tr.routes(); len(routes) != 4 {
+		t.Errorf("expecting 4 routes, got %d", len(routes))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting a panic for an optional param that isn't the last segment")
+		}
+	}()
+	tr.InsertRoute(mGET, "/users/{id?}/posts", hFiles)
+}
+
 func TestTreeFindPattern(t *testing.T) {
 	hStub1 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 	hStub2 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
@@ -444,6 +543,57 @@ func TestTreeFindPattern(t *testing.T) {
 	}
 }
 
+func TestTreeRemoveRoute(t *testing.T) {
+	hStub1 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	hStub2 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	hStub3 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tr := &node{}
+	tr.InsertRoute(mGET, "/articles", hStub1)
+	tr.InsertRoute(mGET, "/articles/{id}", hStub2)
+	tr.InsertRoute(mPOST, "/articles/{id}", hStub2)
+	tr.InsertRoute(mGET, "/artists/{id}/albums", hStub3)
+
+	if tr.RemoveRoute(mGET, "/articles/{slug}") {
+		t.Errorf("remove of unknown pattern should fail")
+	}
+	if tr.RemoveRoute(mPUT, "/articles/{id}") {
+		t.Errorf("remove of unknown method should fail")
+	}
+
+	if !tr.RemoveRoute(mGET, "/articles/{id}") {
+		t.Fatalf("remove GET /articles/{id} failed")
+	}
+	rctx := NewRouteContext()
+	if _, _, h := tr.FindRoute(rctx, mGET, "/articles/1"); h != nil {
+		t.Errorf("GET /articles/1 should no longer route")
+	}
+	rctx.Reset()
+	if _, _, h := tr.FindRoute(rctx, mPOST, "/articles/1"); h == nil {
+		t.Errorf("POST /articles/1 should still route")
+	}
+
+	// Removing the last endpoint on a branch prunes its nodes
+	if !tr.RemoveRoute(mGET, "/artists/{id}/albums") {
+		t.Fatalf("remove GET /artists/{id}/albums failed")
+	}
+	if tr.findPattern("/artists/{id}/albums") {
+		t.Errorf("/artists/{id}/albums should have been pruned")
+	}
+
+	// The remaining routes are merged back under a single static node
+	if !tr.RemoveRoute(mPOST, "/articles/{id}") {
+		t.Fatalf("remove POST /articles/{id} failed")
+	}
+	if len(tr.children[ntStatic]) != 1 || tr.children[ntStatic][0].prefix != "/articles" {
+		t.Errorf("expecting a single compressed '/articles' node")
+	}
+	rctx.Reset()
+	if _, _, h := tr.FindRoute(rctx, mGET, "/articles"); fmt.Sprintf("%v", h) != fmt.Sprintf("%v", hStub1) {
+		t.Errorf("GET /articles should still route")
+	}
+}
+
 func debugPrintTree(parent int, i int, n *node, label byte) bool {
 	numEdges := 0
 	for _, nds := range n.children {
@@ -521,3 +671,34 @@ func TestWalker(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestWalkSorted(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	r := New()
+	r.Post("/users", h)
+	r.Get("/users", h)
+	r.Route("/api", func(r Router) {
+		r.Delete("/items/{id}", h)
+		r.Get("/items/{id}", h)
+	})
+	r.Get("/", h)
+
+	var routes []string
+	if err := WalkSorted(r, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"GET /",
+		"DELETE /api/items/{id}",
+		"GET /api/items/{id}",
+		"GET /users",
+		"POST /users",
+	}
+	if fmt.Sprint(routes) != fmt.Sprint(expected) {
+		t.Fatalf("expecting routes %v, got %v", expected, routes)
+	}
+}